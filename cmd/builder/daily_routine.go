@@ -1,18 +1,37 @@
 package main
 
+import (
+	"fmt"
+	"strings"
+)
+
 type dailyRoutine struct {
-	familyTime    int
-	work          int
-	sleep         string
-	eat           string
-	programming   string
+	familyTime    HourRange
+	work          HourRange
+	sleep         HourRange
+	eat           Meals
+	programming   HourRange
 	hasHobby      bool
 	exercise      bool
 	languageStudy bool
 }
 
+// TotalHours is the time the routine schedules, from the sum of the
+// minimums to the sum of the maximums.
+func (r dailyRoutine) TotalHours() HourRange {
+	return r.familyTime.Add(r.work).Add(r.sleep).Add(r.programming)
+}
+
+func (r dailyRoutine) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "{familyTime:%v work:%v sleep:%v eat:%v programming:%v", r.familyTime, r.work, r.sleep, r.eat, r.programming)
+	fmt.Fprintf(&b, " hasHobby:%t exercise:%t languageStudy:%t}", r.hasHobby, r.exercise, r.languageStudy)
+	return b.String()
+}
+
 type DailyRoutineBuilder struct {
 	dailyRoutine dailyRoutine
+	parseErrors  []FieldError // Strings inválidas, relatadas por Build
 }
 
 func NewDailyRoutineBuilder() *DailyRoutineBuilder {
@@ -20,23 +39,32 @@ func NewDailyRoutineBuilder() *DailyRoutineBuilder {
 }
 
 func (b *DailyRoutineBuilder) SetFamilyTime(hours int) *DailyRoutineBuilder {
-	b.dailyRoutine.familyTime = hours
+	b.dailyRoutine.familyTime = Hours(hours)
 	return b
 }
 func (b *DailyRoutineBuilder) SetWork(hours int) *DailyRoutineBuilder {
-	b.dailyRoutine.work = hours
+	b.dailyRoutine.work = Hours(hours)
 	return b
 }
+
+// SetSleep, SetEat and SetProgramming accept the human forms read by
+// ParseHourRange and ParseMeals; a string that does not parse is
+// reported by Build.
 func (b *DailyRoutineBuilder) SetSleep(hours string) *DailyRoutineBuilder {
-	b.dailyRoutine.sleep = hours
+	b.dailyRoutine.sleep = b.parseHours("sleep", hours)
 	return b
 }
 func (b *DailyRoutineBuilder) SetEat(meals string) *DailyRoutineBuilder {
-	b.dailyRoutine.eat = meals
+	b.clearParseError("eat")
+	n, err := ParseMeals(meals)
+	if err != nil {
+		b.parseErrors = append(b.parseErrors, FieldError{"eat", err.Error()})
+	}
+	b.dailyRoutine.eat = n
 	return b
 }
 func (b *DailyRoutineBuilder) SetProgramming(hours string) *DailyRoutineBuilder {
-	b.dailyRoutine.programming = hours
+	b.dailyRoutine.programming = b.parseHours("programming", hours)
 	return b
 }
 func (b *DailyRoutineBuilder) SetHobby(hasHobby bool) *DailyRoutineBuilder {
@@ -51,9 +79,59 @@ func (b *DailyRoutineBuilder) SetLanguageStudy(language_study bool) *DailyRoutin
 	b.dailyRoutine.languageStudy = language_study
 	return b
 }
-func (b *DailyRoutineBuilder) Build() dailyRoutine {
-	return b.dailyRoutine
+
+// Typed setters, for callers that already have the values.
+func (b *DailyRoutineBuilder) SetFamilyTimeRange(r HourRange) *DailyRoutineBuilder {
+	b.dailyRoutine.familyTime = r
+	return b
+}
+func (b *DailyRoutineBuilder) SetWorkRange(r HourRange) *DailyRoutineBuilder {
+	b.dailyRoutine.work = r
+	return b
+}
+func (b *DailyRoutineBuilder) SetSleepRange(r HourRange) *DailyRoutineBuilder {
+	b.clearParseError("sleep")
+	b.dailyRoutine.sleep = r
+	return b
+}
+func (b *DailyRoutineBuilder) SetMeals(n Meals) *DailyRoutineBuilder {
+	b.clearParseError("eat")
+	b.dailyRoutine.eat = n
+	return b
+}
+func (b *DailyRoutineBuilder) SetProgrammingRange(r HourRange) *DailyRoutineBuilder {
+	b.clearParseError("programming")
+	b.dailyRoutine.programming = r
+	return b
+}
+
+// Build returns the routine, or a *ValidationError listing every rule it
+// breaks.
+func (b *DailyRoutineBuilder) Build() (dailyRoutine, error) {
+	if err := validate(b.dailyRoutine, b.parseErrors); err != nil {
+		return dailyRoutine{}, err
+	}
+	return b.dailyRoutine, nil
 }
 func NewDailyRoutine() *DailyRoutineBuilder {
 	return NewDailyRoutineBuilder()
 }
+
+func (b *DailyRoutineBuilder) parseHours(field, s string) HourRange {
+	b.clearParseError(field)
+	r, err := ParseHourRange(s)
+	if err != nil {
+		b.parseErrors = append(b.parseErrors, FieldError{field, err.Error()})
+	}
+	return r
+}
+
+// clearParseError forgets the parse error of a field that is set again.
+func (b *DailyRoutineBuilder) clearParseError(field string) {
+	for i, e := range b.parseErrors {
+		if e.Field == field {
+			b.parseErrors = append(b.parseErrors[:i], b.parseErrors[i+1:]...)
+			return
+		}
+	}
+}
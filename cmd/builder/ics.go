@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ICSOptions places a weekly schedule on the calendar.
+type ICSOptions struct {
+	// Start is the first day of the schedule; the zero value means today.
+	Start time.Time
+	// Weeks bounds the recurrence; 0 repeats forever.
+	Weeks int
+	// Wake is when each day starts, after sleep; the default is 07:00.
+	Wake time.Duration
+	// Now stamps the events (DTSTAMP); the default is time.Now.
+	Now time.Time
+}
+
+const defaultWake = 7 * time.Hour
+
+// icsBlock is one activity of a day, as an offset from midnight.
+type icsBlock struct {
+	summary     string
+	description string
+	start, dur  time.Duration
+}
+
+// dayBlocks lays a routine out from wake, one activity after another,
+// with sleep ending at the next wake. Ranges use their minimum, as in
+// validation. This is a fixed order, not a planner; a routine whose
+// activities do not fit in a day is an error, so every block has a
+// positive duration and ends before the next day starts.
+func (s weeklySchedule) dayBlocks(r dailyRoutine, wake time.Duration) ([]icsBlock, error) {
+	if total := s.dayTotal(r); total > dayLength {
+		return nil, fmt.Errorf("%s of activities do not fit in a day", shortDuration(total))
+	}
+	var blocks []icsBlock
+	at := wake
+	add := func(summary string, h HourRange) {
+		if h.Min <= 0 {
+			return
+		}
+		blocks = append(blocks, icsBlock{summary, h.String(), at, h.Min})
+		at += h.Min
+	}
+	add("Work", r.work)
+	add("Programming", r.programming)
+	if r.exercise {
+		add("Exercise", Exactly(s.exerciseSession))
+	}
+	if r.languageStudy {
+		add("Language study", Exactly(s.languageStudySession))
+	}
+	add("Family time", r.familyTime)
+	if r.sleep.Min > 0 {
+		blocks = append(blocks, icsBlock{"Sleep", r.sleep.String(), wake + dayLength - r.sleep.Min, r.sleep.Min})
+	}
+	return blocks, nil
+}
+
+// icsDays are the RFC 5545 weekday codes, indexed by time.Weekday.
+var icsDays = [7]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// WriteICS writes s as an iCalendar with one weekly recurring event per
+// activity; days where an activity has the same time share the event.
+// Times are floating: they follow the time zone of whoever opens it.
+func (s weeklySchedule) WriteICS(w io.Writer, opts ICSOptions) error {
+	if opts.Start.IsZero() {
+		opts.Start = time.Now()
+	}
+	if opts.Wake == 0 {
+		opts.Wake = defaultWake
+	}
+	if opts.Wake < 0 || opts.Wake >= dayLength {
+		return fmt.Errorf("ics: wake time %v is not within a day", opts.Wake)
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+	first := time.Date(opts.Start.Year(), opts.Start.Month(), opts.Start.Day(), 0, 0, 0, 0, time.UTC)
+
+	// Agrupa os dias em que a mesma atividade acontece no mesmo horário
+	type event struct {
+		icsBlock
+		days []time.Weekday
+	}
+	var events []*event
+	index := make(map[icsBlock]*event)
+	for _, day := range weekOrder {
+		blocks, err := s.dayBlocks(s.days[day], opts.Wake)
+		if err != nil {
+			return fmt.Errorf("ics: %s: %w", day, err)
+		}
+		for _, b := range blocks {
+			ev, ok := index[b]
+			if !ok {
+				ev = &event{icsBlock: b}
+				index[b] = ev
+				events = append(events, ev)
+			}
+			ev.days = append(ev.days, day)
+		}
+	}
+
+	iw := &icsWriter{w: bufio.NewWriter(w)}
+	iw.line("BEGIN:VCALENDAR")
+	iw.line("VERSION:2.0")
+	iw.line("PRODID:-//HGalassi//patterns builder//EN")
+	iw.line("CALSCALE:GREGORIAN")
+	for _, ev := range events {
+		byDay := make([]string, len(ev.days))
+		for i, d := range ev.days {
+			byDay[i] = icsDays[d]
+		}
+		start := firstOn(first, ev.days).Add(ev.start)
+		rule := "RRULE:FREQ=WEEKLY;BYDAY=" + strings.Join(byDay, ",")
+		if opts.Weeks > 0 {
+			until := first.AddDate(0, 0, 7*opts.Weeks).Add(-time.Second)
+			rule += ";UNTIL=" + until.Format(icsTime)
+		}
+
+		iw.line("BEGIN:VEVENT")
+		iw.line(fmt.Sprintf("UID:%s-%s-%s@patterns", strings.ToLower(strings.ReplaceAll(ev.summary, " ", "-")),
+			start.Format("1504"), strings.Join(byDay, "")))
+		iw.line("DTSTAMP:" + opts.Now.UTC().Format(icsTime) + "Z")
+		iw.line("DTSTART:" + start.Format(icsTime))
+		iw.line("DTEND:" + start.Add(ev.dur).Format(icsTime))
+		iw.line(rule)
+		iw.line("SUMMARY:" + icsText(ev.summary))
+		iw.line("DESCRIPTION:" + icsText(ev.description))
+		iw.line("END:VEVENT")
+	}
+	iw.line("END:VCALENDAR")
+	if iw.err != nil {
+		return iw.err
+	}
+	return iw.w.Flush()
+}
+
+// SaveICS writes s as an iCalendar file.
+func (s weeklySchedule) SaveICS(path string, opts ICSOptions) error {
+	var buf bytes.Buffer
+	if err := s.WriteICS(&buf, opts); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// icsTime is the local ("floating") DATE-TIME form of RFC 5545.
+const icsTime = "20060102T150405"
+
+// firstOn returns the first date on or after from that falls on one of
+// days.
+func firstOn(from time.Time, days []time.Weekday) time.Time {
+	for i := range 7 {
+		if d := from.AddDate(0, 0, i); slices.Contains(days, d.Weekday()) {
+			return d
+		}
+	}
+	return from
+}
+
+// icsText escapes a TEXT value.
+func icsText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+// icsWriter ends lines with CRLF and folds them at 75 octets, as RFC 5545
+// requires. The first error is kept and later writes are skipped.
+type icsWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (iw *icsWriter) line(s string) {
+	if iw.err != nil {
+		return
+	}
+	limit := 75
+	for len(s) > limit {
+		// Não corta no meio de um caractere UTF-8
+		cut := limit
+		for cut > 0 && s[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		if _, iw.err = iw.w.WriteString(s[:cut] + "\r\n "); iw.err != nil {
+			return
+		}
+		s = s[cut:]
+		limit = 74 // O espaço da continuação conta
+	}
+	_, iw.err = iw.w.WriteString(s + "\r\n")
+}
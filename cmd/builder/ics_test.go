@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+	"time"
+)
+
+// icsEvents splits an iCalendar into the properties of each VEVENT,
+// unfolding continuation lines.
+func icsEvents(t *testing.T, ics string) []map[string]string {
+	t.Helper()
+	if !strings.HasSuffix(ics, "END:VCALENDAR\r\n") {
+		t.Fatalf("calendar not terminated:\n%s", ics)
+	}
+	var events []map[string]string
+	var ev map[string]string
+	for _, line := range strings.Split(strings.ReplaceAll(ics, "\r\n ", ""), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line longer than 75 octets: %q", line)
+		}
+		key, value, _ := strings.Cut(line, ":")
+		switch {
+		case line == "BEGIN:VEVENT":
+			ev = make(map[string]string)
+		case line == "END:VEVENT":
+			events = append(events, ev)
+			ev = nil
+		case ev != nil:
+			ev[key] = value
+		}
+	}
+	return events
+}
+
+func TestWriteICS(t *testing.T) {
+	workday, weekend := testWeek(t)
+	s, err := NewWeeklyScheduleBuilder().
+		SetTemplate(TemplateWeekday, workday).
+		SetTemplate(TemplateWeekend, weekend).
+		OverrideDay(time.Friday, func(r *DailyRoutineBuilder) { r.SetWork(6) }).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, wake := range []time.Duration{0, 5 * time.Hour, 9*time.Hour + 30*time.Minute} {
+		var b strings.Builder
+		err := s.WriteICS(&b, ICSOptions{
+			Start: time.Date(2026, 1, 3, 0, 0, 0, 0, time.UTC), // Sábado
+			Weeks: 2,
+			Wake:  wake,
+			Now:   time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		events := icsEvents(t, b.String())
+		if len(events) == 0 {
+			t.Fatal("no events")
+		}
+		uids := make(map[string]bool)
+		for _, ev := range events {
+			start, err1 := time.Parse(icsTime, ev["DTSTART"])
+			end, err2 := time.Parse(icsTime, ev["DTEND"])
+			if err1 != nil || err2 != nil {
+				t.Fatalf("bad times in %v", ev)
+			}
+			if !start.Before(end) {
+				t.Errorf("wake %v: %s: DTSTART %s is not before DTEND %s", wake, ev["SUMMARY"], ev["DTSTART"], ev["DTEND"])
+			}
+			if !strings.HasPrefix(ev["RRULE"], "FREQ=WEEKLY;BYDAY=") || !strings.HasSuffix(ev["RRULE"], ";UNTIL=20260116T235959") {
+				t.Errorf("RRULE = %q", ev["RRULE"])
+			}
+			if uids[ev["UID"]] {
+				t.Errorf("duplicate UID %s", ev["UID"])
+			}
+			uids[ev["UID"]] = true
+		}
+	}
+}
+
+func TestWriteICSRejectsOverflow(t *testing.T) {
+	// Uma agenda montada sem Build, com um dia que não cabe em 24 horas
+	overflow := dailyRoutine{work: Hours(16), programming: Hours(7), sleep: Hours(1), eat: 3, exercise: true, languageStudy: true}
+	var s weeklySchedule
+	for i := range s.days {
+		s.days[i] = overflow
+	}
+	s.exerciseSession, s.languageStudySession = defaultExerciseSession, defaultLanguageStudySession
+
+	var b strings.Builder
+	err := s.WriteICS(&b, ICSOptions{Start: time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)})
+	if err == nil || !strings.Contains(err.Error(), "do not fit in a day") {
+		t.Fatalf("err = %v, want the day rejected", err)
+	}
+	if err := s.WriteICS(&b, ICSOptions{Wake: 25 * time.Hour}); err == nil {
+		t.Error("wake after midnight accepted")
+	}
+}
+
+func TestICSWriterFolds(t *testing.T) {
+	var b strings.Builder
+	iw := &icsWriter{w: bufio.NewWriter(&b)}
+	long := "DESCRIPTION:" + strings.Repeat("á", 100)
+	iw.line(long)
+	iw.w.Flush()
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n")
+	for i, line := range lines {
+		if len(line) > 75 {
+			t.Errorf("line %d has %d octets", i, len(line))
+		}
+		if i > 0 && !strings.HasPrefix(line, " ") {
+			t.Errorf("continuation line %d does not start with a space", i)
+		}
+	}
+	if got := strings.ReplaceAll(b.String(), "\r\n ", ""); got != long+"\r\n" {
+		t.Errorf("unfolded = %q", got)
+	}
+}
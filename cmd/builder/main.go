@@ -1,9 +1,14 @@
 package main
 
-import "fmt"
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
 
 func main() {
-	weeklyRoutine := NewDailyRoutine().SetEat("3 meals").
+	workdayRoutine, err := NewDailyRoutine().SetEat("3 meals").
 		SetFamilyTime(2).
 		SetWork(8).
 		SetSleep("7-8 hours").
@@ -12,15 +17,95 @@ func main() {
 		SetExercise(true).
 		SetLanguageStudy(true).
 		Build()
+	if err != nil {
+		fmt.Println(err)
+	}
 
-	fmt.Printf("%+v\n", weeklyRoutine)
+	fmt.Printf("%+v\n", workdayRoutine)
 
-	dailyRoutine := NewDailyRoutine().SetEat("2 meals").
+	weekendRoutine, err := NewDailyRoutine().SetEat("2 meals").
 		SetFamilyTime(1).
 		SetSleep("6 hours").
 		SetProgramming("1 hour").
 		SetHobby(false).
 		Build()
+	if err != nil {
+		fmt.Println(err)
+	}
 
-	fmt.Printf("%+v\n", dailyRoutine)
+	fmt.Printf("%+v\n", weekendRoutine)
+
+	// Todas as violações aparecem juntas, não só a primeira
+	_, err = NewDailyRoutine().SetEat("3 meals").
+		SetWork(20).
+		SetFamilyTime(10).
+		SetProgramming("lots").
+		Build()
+	fmt.Println(err)
+
+	// Ida e volta por arquivo: a rotina pode ser editada sem recompilar
+	dir, err := os.MkdirTemp("", "rotina")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"rotina.json", "rotina.yaml", "rotina.toml"} {
+		path := filepath.Join(dir, name)
+		if err := SaveRoutineFile(path, workdayRoutine); err != nil {
+			fmt.Println(err)
+			continue
+		}
+		b, err := NewDailyRoutineBuilderFromFile(path)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		loaded, err := b.Build()
+		fmt.Printf("%s: igual=%t err=%v\n", name, loaded == workdayRoutine, err)
+	}
+
+	// Semana: modelos para dias úteis e fim de semana, com a sexta mais curta
+	week, err := NewWeeklyScheduleBuilder().
+		SetTemplate(TemplateWeekday, workdayRoutine).
+		SetTemplate(TemplateWeekend, weekendRoutine).
+		OverrideDay(time.Friday, func(r *DailyRoutineBuilder) { r.SetWork(6).SetExercise(false) }).
+		Build()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(week)
+
+	icsPath := filepath.Join(dir, "semana.ics")
+	if err := week.SaveICS(icsPath, ICSOptions{Weeks: 4}); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("calendário salvo em", icsPath)
+
+	// Linha do tempo: sono fixo terminando às 07:00 e o resto encaixado pelo solver
+	timeline, err := NewTimelineBuilder(workdayRoutine).
+		Window("work", 9*time.Hour, 18*time.Hour).
+		Window("familyTime", 18*time.Hour, 23*time.Hour).
+		Before("exercise", "work").
+		Build()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Print(timeline.Table())
+	svgPath := filepath.Join(dir, "dia.svg")
+	if err := timeline.SaveSVG(svgPath); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("visão do dia salva em", svgPath)
+
+	// Restrições impossíveis: o erro aponta o menor conjunto em conflito
+	_, err = NewTimelineBuilder(workdayRoutine).
+		Window("familyTime", 18*time.Hour, 20*time.Hour).
+		Window("programming", 18*time.Hour, 20*time.Hour).
+		Build()
+	fmt.Println(err)
 }
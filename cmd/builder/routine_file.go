@@ -0,0 +1,502 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// RoutineFormat is a file format a routine can be saved in.
+type RoutineFormat int
+
+const (
+	FormatJSON RoutineFormat = iota
+	FormatYAML
+	FormatTOML
+)
+
+func (f RoutineFormat) String() string {
+	switch f {
+	case FormatJSON:
+		return "json"
+	case FormatYAML:
+		return "yaml"
+	case FormatTOML:
+		return "toml"
+	}
+	return fmt.Sprintf("RoutineFormat(%d)", int(f))
+}
+
+// FormatFromPath picks the format from the file extension.
+func FormatFromPath(path string) (RoutineFormat, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON, nil
+	case ".yaml", ".yml":
+		return FormatYAML, nil
+	case ".toml":
+		return FormatTOML, nil
+	}
+	return 0, fmt.Errorf("routine: unknown format for %q (want .json, .yaml, .yml or .toml)", path)
+}
+
+// routineDoc is the file form of a routine. Amounts are kept as the
+// human strings the setters accept ("7-8 hours", "3 meals"), so a file
+// reads like the code that builds the same routine. Keys are the json
+// tags in every format.
+type routineDoc struct {
+	FamilyTime    string `json:"family_time,omitempty"`
+	Work          string `json:"work,omitempty"`
+	Sleep         string `json:"sleep,omitempty"`
+	Eat           string `json:"eat,omitempty"`
+	Programming   string `json:"programming,omitempty"`
+	Hobby         bool   `json:"hobby"`
+	Exercise      bool   `json:"exercise"`
+	LanguageStudy bool   `json:"language_study"`
+}
+
+func docOf(r dailyRoutine) routineDoc {
+	text := func(h HourRange) string {
+		if h.IsZero() {
+			return ""
+		}
+		return h.String()
+	}
+	d := routineDoc{
+		FamilyTime:    text(r.familyTime),
+		Work:          text(r.work),
+		Sleep:         text(r.sleep),
+		Programming:   text(r.programming),
+		Hobby:         r.hasHobby,
+		Exercise:      r.exercise,
+		LanguageStudy: r.languageStudy,
+	}
+	if r.eat != 0 {
+		d.Eat = r.eat.String()
+	}
+	return d
+}
+
+// builder fills a builder through the same parsing as the string
+// setters, so a bad value in a file is reported by Build with the rest.
+func (d routineDoc) builder() *DailyRoutineBuilder {
+	b := NewDailyRoutineBuilder()
+	for _, f := range []struct {
+		name, text string
+		dst        *HourRange
+	}{
+		{"familyTime", d.FamilyTime, &b.dailyRoutine.familyTime},
+		{"work", d.Work, &b.dailyRoutine.work},
+		{"sleep", d.Sleep, &b.dailyRoutine.sleep},
+		{"programming", d.Programming, &b.dailyRoutine.programming},
+	} {
+		if f.text != "" {
+			*f.dst = b.parseHours(f.name, f.text)
+		}
+	}
+	if d.Eat != "" {
+		b.SetEat(d.Eat)
+	}
+	return b.SetHobby(d.Hobby).SetExercise(d.Exercise).SetLanguageStudy(d.LanguageStudy)
+}
+
+func (r dailyRoutine) MarshalJSON() ([]byte, error) {
+	return json.Marshal(docOf(r))
+}
+
+// UnmarshalJSON validates like Build: an invalid routine is an error,
+// and so is an unknown key, as when reading a file.
+func (r *dailyRoutine) UnmarshalJSON(data []byte) error {
+	var d routineDoc
+	if err := decodeJSONDoc(bytes.NewReader(data), &d); err != nil {
+		return err
+	}
+	built, err := d.builder().Build()
+	if err != nil {
+		return err
+	}
+	*r = built
+	return nil
+}
+
+// decodeJSONDoc reads exactly one JSON object with only the keys of
+// routineDoc. Both JSON paths go through it so they accept the same input.
+func decodeJSONDoc(rd io.Reader, d *routineDoc) error {
+	dec := json.NewDecoder(rd)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(d); err != nil {
+		return fmt.Errorf("routine: %w", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("routine: unexpected data after the routine")
+	}
+	return nil
+}
+
+// EncodeRoutine writes r to w in format.
+func EncodeRoutine(w io.Writer, r dailyRoutine, format RoutineFormat) error {
+	d := docOf(r)
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(d)
+	case FormatYAML:
+		return encodeFlat(w, d, format)
+	case FormatTOML:
+		return encodeFlat(w, d, format)
+	}
+	return fmt.Errorf("routine: unknown format %v", format)
+}
+
+// DecodeRoutine reads a routine in format from rd and validates it.
+func DecodeRoutine(rd io.Reader, format RoutineFormat) (dailyRoutine, error) {
+	b, err := decodeBuilder(rd, format)
+	if err != nil {
+		return dailyRoutine{}, err
+	}
+	return b.Build()
+}
+
+// SaveRoutineFile writes r to path in the format of its extension.
+func SaveRoutineFile(path string, r dailyRoutine) error {
+	format, err := FormatFromPath(path)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := EncodeRoutine(&buf, r, format); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// NewDailyRoutineBuilderFromFile returns a builder hydrated from a JSON,
+// YAML or TOML file, chosen by extension. Syntax errors are returned
+// here; invalid values are reported by Build, and setters called on the
+// builder override what the file says.
+func NewDailyRoutineBuilderFromFile(path string) (*DailyRoutineBuilder, error) {
+	format, err := FormatFromPath(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	b, err := decodeBuilder(f, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return b, nil
+}
+
+func decodeBuilder(rd io.Reader, format RoutineFormat) (*DailyRoutineBuilder, error) {
+	var d routineDoc
+	switch format {
+	case FormatJSON:
+		if err := decodeJSONDoc(rd, &d); err != nil {
+			return nil, err
+		}
+	case FormatYAML, FormatTOML:
+		if err := decodeFlat(rd, &d, format); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("routine: unknown format %v", format)
+	}
+	return d.builder(), nil
+}
+
+// encodeFlat and decodeFlat handle a strict subset of YAML and TOML, all
+// a flat struct of strings and bools needs; the repo avoids external
+// dependencies. A file is a list of top-level "key: value" (YAML) or
+// "key = value" (TOML) lines, with blank lines, "#" comments and, in
+// YAML, one leading "---". A value is one of:
+//
+//   - true or false (YAML also accepts True, TRUE, False and FALSE);
+//   - a double-quoted string with the escapes \" \\ \b \f \n \r \t \uXXXX
+//     and \UXXXXXXXX;
+//   - a single-quoted string, with two quotes in a row for one in YAML
+//     and no escapes at all in TOML;
+//   - in YAML only, a plain string.
+//
+// Anything else is an error rather than a guess: indented values, lists,
+// flow collections, anchors, tags, block scalars, multiple documents and
+// TOML tables.
+func encodeFlat(w io.Writer, doc any, format RoutineFormat) error {
+	sep := ": "
+	if format == FormatTOML {
+		sep = " = "
+	}
+	v := reflect.ValueOf(doc)
+	bw := bufio.NewWriter(w)
+	for i := range v.NumField() {
+		key, omitEmpty := flatKey(v.Type().Field(i))
+		field := v.Field(i)
+		if omitEmpty && field.IsZero() {
+			continue
+		}
+		var value string
+		switch field.Kind() {
+		case reflect.String:
+			value = flatQuote(field.String())
+		case reflect.Bool:
+			value = strconv.FormatBool(field.Bool())
+		default:
+			return fmt.Errorf("routine: cannot encode %v", field.Type())
+		}
+		fmt.Fprintf(bw, "%s%s%s\n", key, sep, value)
+	}
+	return bw.Flush()
+}
+
+func decodeFlat(rd io.Reader, doc any, format RoutineFormat) error {
+	v := reflect.ValueOf(doc).Elem()
+	fields := make(map[string]reflect.Value, v.NumField())
+	for i := range v.NumField() {
+		key, _ := flatKey(v.Type().Field(i))
+		fields[key] = v.Field(i)
+	}
+	sep := ":"
+	if format == FormatTOML {
+		sep = "="
+	}
+
+	seen := make(map[string]bool)
+	started := false // Já passou do "---" ou de uma chave
+	sc := bufio.NewScanner(rd)
+	for n := 1; sc.Scan(); n++ {
+		text := sc.Text()
+		line := strings.TrimSpace(text)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		if text[0] == ' ' || text[0] == '\t' {
+			return fmt.Errorf("routine: line %d: indented values are not supported, got %q", n, text)
+		}
+		if format == FormatYAML && line == "---" {
+			if started {
+				return fmt.Errorf("routine: line %d: multiple documents are not supported", n)
+			}
+			started = true
+			continue
+		}
+		if format == FormatTOML && line[0] == '[' {
+			return fmt.Errorf("routine: line %d: tables are not supported, got %q", n, line)
+		}
+		started = true
+
+		key, raw, ok := strings.Cut(line, sep)
+		if !ok || format == FormatYAML && raw != "" && raw[0] != ' ' && raw[0] != '\t' {
+			return fmt.Errorf("routine: line %d: want key%s value, got %q", n, sep, line)
+		}
+		key = strings.TrimSpace(key)
+		field, known := fields[key]
+		if !known {
+			return fmt.Errorf("routine: line %d: unknown key %q", n, key)
+		}
+		if seen[key] {
+			return fmt.Errorf("routine: line %d: duplicate key %q", n, key)
+		}
+		seen[key] = true
+
+		value, quoted, err := flatScalar(raw, format)
+		if err != nil {
+			return fmt.Errorf("routine: line %d: %s: %w", n, key, err)
+		}
+		switch field.Kind() {
+		case reflect.String:
+			if !quoted && format == FormatTOML {
+				return fmt.Errorf("routine: line %d: %s: strings must be quoted in TOML, got %s", n, key, value)
+			}
+			field.SetString(value)
+		case reflect.Bool:
+			b, ok := flatBool(value, format)
+			if quoted || !ok {
+				return fmt.Errorf("routine: line %d: %s: want true or false, got %s", n, key, strings.TrimSpace(raw))
+			}
+			field.SetBool(b)
+		}
+	}
+	return sc.Err()
+}
+
+// flatScalar reads the value after the separator, dropping a trailing
+// comment. quoted tells a string from a bare word such as true.
+func flatScalar(raw string, format RoutineFormat) (value string, quoted bool, err error) {
+	raw = strings.TrimSpace(raw)
+	var end int // Índice da aspa que fecha o valor
+	switch {
+	case raw == "":
+		return "", false, errors.New("missing value")
+	case raw[0] == '"':
+		if end = closingQuote(raw); end < 0 {
+			return "", false, fmt.Errorf("unterminated string %s", raw)
+		}
+		if value, err = flatUnquote(raw[1:end]); err != nil {
+			return "", false, err
+		}
+	case raw[0] == '\'':
+		if value, end = singleQuoted(raw, format == FormatYAML); end < 0 {
+			return "", false, fmt.Errorf("unterminated string %s", raw)
+		}
+	default:
+		if i := strings.Index(raw, " #"); i >= 0 {
+			raw = strings.TrimSpace(raw[:i])
+		}
+		if format == FormatYAML && strings.ContainsRune("[]{}&*!|>%@`", rune(raw[0])) {
+			return "", false, fmt.Errorf("%s: flow collections, anchors, tags and block scalars are not supported", raw)
+		}
+		return raw, false, nil
+	}
+	if rest := strings.TrimSpace(raw[end+1:]); rest != "" && rest[0] != '#' {
+		return "", false, fmt.Errorf("unexpected %q after string", rest)
+	}
+	return value, true, nil
+}
+
+// singleQuoted returns the body of the single-quoted string at the start
+// of s and the index of its closing quote, or -1 if there is none. With
+// doubled, two quotes in a row stand for one, as in YAML; TOML literal
+// strings have no escapes at all.
+func singleQuoted(s string, doubled bool) (string, int) {
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		if s[i] != '\'' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if doubled && i+1 < len(s) && s[i+1] == '\'' {
+			b.WriteByte('\'')
+			i++
+			continue
+		}
+		return b.String(), i
+	}
+	return "", -1
+}
+
+func flatBool(s string, format RoutineFormat) (value, ok bool) {
+	switch s {
+	case "true":
+		return true, true
+	case "false":
+		return false, true
+	}
+	if format == FormatYAML {
+		switch s {
+		case "True", "TRUE":
+			return true, true
+		case "False", "FALSE":
+			return false, true
+		}
+	}
+	return false, false
+}
+
+// flatQuote double-quotes s with only the escapes YAML and TOML share.
+func flatQuote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"', '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+				continue
+			}
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// flatUnquote decodes the body of a double-quoted string.
+func flatUnquote(s string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i++; i == len(s) {
+			return "", errors.New("string ends in a backslash")
+		}
+		switch c := s[i]; c {
+		case '"', '\\':
+			b.WriteByte(c)
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'u', 'U':
+			digits := 4
+			if c == 'U' {
+				digits = 8
+			}
+			if i+digits >= len(s) {
+				return "", fmt.Errorf(`short escape \%s`, s[i:])
+			}
+			code, err := strconv.ParseUint(s[i+1:i+1+digits], 16, 32)
+			if err != nil || !utf8.ValidRune(rune(code)) {
+				return "", fmt.Errorf(`invalid escape \%s`, s[i:i+1+digits])
+			}
+			b.WriteRune(rune(code))
+			i += digits
+		default:
+			return "", fmt.Errorf(`unsupported escape \%c`, c)
+		}
+	}
+	return b.String(), nil
+}
+
+// closingQuote returns the index of the quote ending the double-quoted
+// string at the start of s, skipping escaped quotes.
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+func flatKey(f reflect.StructField) (key string, omitEmpty bool) {
+	key, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+	return key, opts == "omitempty"
+}
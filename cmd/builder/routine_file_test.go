@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRoutineFileRoundTrip(t *testing.T) {
+	routines := map[string]dailyRoutine{
+		"full": mustRoutine(t, NewDailyRoutine().SetEat("3 meals").SetFamilyTime(2).SetWork(8).
+			SetSleep("7-8 hours").SetProgramming("90 minutes").SetHobby(true).SetExercise(true).SetLanguageStudy(true)),
+		"minimal": mustRoutine(t, NewDailyRoutine().SetEat("2 meals").SetSleep("9 hours")),
+	}
+	for name, r := range routines {
+		for _, ext := range []string{".json", ".yaml", ".yml", ".toml"} {
+			path := filepath.Join(t.TempDir(), "rotina"+ext)
+			if err := SaveRoutineFile(path, r); err != nil {
+				t.Fatalf("%s%s: %v", name, ext, err)
+			}
+			b, err := NewDailyRoutineBuilderFromFile(path)
+			if err != nil {
+				t.Fatalf("%s%s: %v", name, ext, err)
+			}
+			if got := mustRoutine(t, b); got != r {
+				t.Errorf("%s%s: read back %v, want %v", name, ext, got, r)
+			}
+		}
+
+		data, err := json.Marshal(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got dailyRoutine
+		if err := json.Unmarshal(data, &got); err != nil || got != r {
+			t.Errorf("%s: json round trip = %v, %v; want %v", name, got, err, r)
+		}
+	}
+}
+
+func TestRoutineFileSetterOverridesFile(t *testing.T) {
+	b, err := decodeBuilder(strings.NewReader("sleep: 8 hours\neat: 3 meals\nwork: 8 hours\n"), FormatYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := mustRoutine(t, b.SetWork(6)); r.work != Hours(6) {
+		t.Errorf("work = %v, want the setter's 6 hours", r.work)
+	}
+}
+
+func TestDecodeFlat(t *testing.T) {
+	tests := []struct {
+		name   string
+		format RoutineFormat
+		input  string
+		sleep  string
+		hobby  bool
+		err    string // Vazio quando a entrada é válida
+	}{
+		{"yaml plain", FormatYAML, "---\nsleep: 8 hours # comentário\nhobby: True\n", "8 hours", true, ""},
+		{"yaml double quoted", FormatYAML, `sleep: "7-8 hours"`, "7-8 hours", false, ""},
+		{"yaml unicode escape", FormatYAML, `sleep: "8\u0020hours"`, "8 hours", false, ""},
+		{"yaml single quoted", FormatYAML, "sleep: 'it''s # not a comment' # comentário", "it's # not a comment", false, ""},
+		{"yaml hash inside plain", FormatYAML, "sleep: 8#hours", "8#hours", false, ""},
+		{"toml", FormatTOML, "sleep = '7-8 hours'\nhobby = false # comentário", "7-8 hours", false, ""},
+		{"toml literal backslash", FormatTOML, `sleep = 'C:\dir'`, `C:\dir`, false, ""},
+		{"toml single quotes are literal", FormatTOML, "sleep = 'it''s'", "", false, `unexpected "'s'" after string`},
+		{"toml bare string", FormatTOML, "sleep = 8 hours", "", false, "strings must be quoted in TOML"},
+		{"toml bool 1", FormatTOML, "hobby = 1", "", false, "hobby: want true or false, got 1"},
+		{"toml bool T", FormatTOML, "hobby = T", "", false, "want true or false"},
+		{"toml bool True", FormatTOML, "hobby = True", "", false, "want true or false"},
+		{"yaml bool yes", FormatYAML, "hobby: yes", "", false, "want true or false"},
+		{"quoted bool", FormatYAML, `hobby: "true"`, "", false, "want true or false"},
+		{"toml table", FormatTOML, "[rotina]", "", false, "tables are not supported"},
+		{"nested", FormatYAML, "sleep:\n  min: 7 hours", "", false, "missing value"},
+		{"indented", FormatYAML, "  sleep: 8 hours", "", false, "indented values are not supported"},
+		{"list", FormatYAML, "- sleep: 8 hours", "", false, "unknown key"},
+		{"flow", FormatYAML, "sleep: [7, 8]", "", false, "not supported"},
+		{"anchor", FormatYAML, "sleep: &s 8 hours", "", false, "not supported"},
+		{"block scalar", FormatYAML, "sleep: |", "", false, "not supported"},
+		{"two documents", FormatYAML, "---\nsleep: 8 hours\n---", "", false, "multiple documents"},
+		{"no space after colon", FormatYAML, "sleep:8 hours", "", false, "want key: value"},
+		{"unknown key", FormatYAML, "naps: 2", "", false, `unknown key "naps"`},
+		{"duplicate key", FormatTOML, "hobby = true\nhobby = false", "", false, `duplicate key "hobby"`},
+		{"unterminated", FormatTOML, `sleep = "8 hours`, "", false, "unterminated string"},
+		{"unterminated single", FormatYAML, "sleep: 'it''", "", false, "unterminated string"},
+		{"bad escape", FormatTOML, `sleep = "8\x20hours"`, "", false, `unsupported escape \x`},
+		{"short unicode escape", FormatTOML, `sleep = "8\u20"`, "", false, "short escape"},
+		{"text after string", FormatYAML, `sleep: "8 hours" extra`, "", false, "after string"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var d routineDoc
+			err := decodeFlat(strings.NewReader(tt.input), &d, tt.format)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("err = %v, want it to mention %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d.Sleep != tt.sleep || d.Hobby != tt.hobby {
+				t.Errorf("sleep = %q, hobby = %t; want %q, %t", d.Sleep, d.Hobby, tt.sleep, tt.hobby)
+			}
+		})
+	}
+}
+
+func TestRoutineFileQuoting(t *testing.T) {
+	for _, s := range []string{`a "quoted" \ value`, "tab\there", "line\nbreak", "bell\a", "ação ✓", "it's"} {
+		for _, format := range []RoutineFormat{FormatYAML, FormatTOML} {
+			line := "sleep" + map[RoutineFormat]string{FormatYAML: ": ", FormatTOML: " = "}[format] + flatQuote(s)
+			var d routineDoc
+			if err := decodeFlat(strings.NewReader(line), &d, format); err != nil || d.Sleep != s {
+				t.Errorf("%v: %s read back as %q, %v; want %q", format, line, d.Sleep, err, s)
+			}
+		}
+	}
+}
+
+func TestRoutineJSONStrict(t *testing.T) {
+	tests := []struct {
+		name, input, err string
+	}{
+		{"unknown key", `{"sleep": "8 hours", "eat": "3 meals", "naps": 2}`, `unknown field "naps"`},
+		{"wrong type", `{"sleep": 8, "eat": "3 meals"}`, "cannot unmarshal"},
+		{"invalid routine", `{"sleep": "30 hours", "eat": "3 meals"}`, "sleep"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Os dois caminhos de JSON aceitam e rejeitam a mesma entrada
+			var r dailyRoutine
+			if err := json.Unmarshal([]byte(tt.input), &r); err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("json.Unmarshal: err = %v, want it to mention %q", err, tt.err)
+			}
+			if _, err := DecodeRoutine(strings.NewReader(tt.input), FormatJSON); err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("DecodeRoutine: err = %v, want it to mention %q", err, tt.err)
+			}
+		})
+	}
+
+	if _, err := DecodeRoutine(strings.NewReader(`{"sleep": "8 hours", "eat": "3 meals"} {}`), FormatJSON); err == nil {
+		t.Error("DecodeRoutine accepted data after the routine")
+	}
+	var buf bytes.Buffer
+	if err := EncodeRoutine(&buf, mustRoutine(t, NewDailyRoutine().SetEat("3 meals").SetSleep("8 hours")), FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecodeRoutine(&buf, FormatJSON); err != nil {
+		t.Errorf("DecodeRoutine of EncodeRoutine output: %v", err)
+	}
+}
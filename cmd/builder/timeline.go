@@ -0,0 +1,626 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	dayLength      = 24 * time.Hour
+	defaultStep    = 15 * time.Minute
+	defaultSleepAt = 23 * time.Hour
+	defaultWakeAt  = 7 * time.Hour
+	mealDuration   = 30 * time.Minute
+	maxSearchNodes = 1_000_000 // Nós de busca antes de desistir
+)
+
+// ErrSearchLimit is returned when the solver gives up before proving
+// that a day has a layout or has none.
+var ErrSearchLimit = errors.New("timeline: search limit reached")
+
+// InfeasibleError lists a set of constraints that cannot hold together in
+// one day. When Minimal is set, dropping any one of them makes the rest
+// fit, so it points at what to change; it is unset when the solver hit its
+// search limit while checking some of them, which are then kept.
+type InfeasibleError struct {
+	Conflicts []string
+	Minimal   bool
+}
+
+func (e *InfeasibleError) Error() string {
+	msg := "timeline: infeasible day; conflicting constraints: "
+	if !e.Minimal {
+		msg = "timeline: infeasible day; constraints involved (not minimal): "
+	}
+	return msg + strings.Join(e.Conflicts, "; ")
+}
+
+// TimelineBuilder places the activities of a routine on a 24-hour day.
+// Sleep is anchored to end at 07:00 and the other activities are flexible
+// blocks that the solver moves, and shrinks within their HourRange, until
+// nothing overlaps. Windows and orderings constrain the flexible blocks.
+type TimelineBuilder struct {
+	step    time.Duration
+	blocks  []timelineBlock
+	anchors map[string]span
+	windows map[string]span
+	orders  [][2]string
+	errs    []error
+}
+
+type timelineBlock struct {
+	name string
+	dur  HourRange
+}
+
+// span is a time-of-day interval; an anchor may cross midnight
+// (start > end), a window may not.
+type span struct{ start, end time.Duration }
+
+func (s span) length() time.Duration {
+	if s.end <= s.start {
+		return s.end + dayLength - s.start
+	}
+	return s.end - s.start
+}
+
+// NewTimelineBuilder starts a timeline with the blocks of r: sleep, for
+// its maximum and ending at 07:00, work, programming, familyTime,
+// exercise and languageStudy sessions and one 30-minute "meal N" per
+// meal, each meal within its share of the waking hours.
+func NewTimelineBuilder(r dailyRoutine) *TimelineBuilder {
+	b := &TimelineBuilder{
+		step:    defaultStep,
+		anchors: make(map[string]span),
+		windows: make(map[string]span),
+	}
+	b.AddBlock("sleep", r.sleep)
+	sleepAt := defaultSleepAt
+	if !r.sleep.IsZero() {
+		sleepAt = (defaultWakeAt - r.sleep.Max%dayLength + dayLength) % dayLength
+	}
+	b.Anchor("sleep", sleepAt, defaultWakeAt)
+	b.AddBlock("work", r.work)
+	b.AddBlock("programming", r.programming)
+	b.AddBlock("familyTime", r.familyTime)
+	if r.exercise {
+		b.AddBlock("exercise", Exactly(defaultExerciseSession))
+	}
+	if r.languageStudy {
+		b.AddBlock("languageStudy", Exactly(defaultLanguageStudySession))
+	}
+	for i := range int(r.eat) {
+		b.AddBlock(fmt.Sprintf("meal %d", i+1), Exactly(mealDuration))
+	}
+	return b
+}
+
+// SetStep sets the grid the solver places blocks on; times and
+// durations are rounded to it. The default is 15 minutes.
+func (b *TimelineBuilder) SetStep(step time.Duration) *TimelineBuilder {
+	b.step = step
+	return b
+}
+
+// AddBlock adds a flexible activity, or changes the duration of one. A
+// zero duration removes it.
+func (b *TimelineBuilder) AddBlock(name string, dur HourRange) *TimelineBuilder {
+	b.blocks = slices.DeleteFunc(b.blocks, func(tb timelineBlock) bool { return tb.name == name })
+	if !dur.IsZero() {
+		b.blocks = append(b.blocks, timelineBlock{name, dur})
+	}
+	return b
+}
+
+// Anchor fixes name at start-end, which may cross midnight, adding the
+// block with that length if needed. An existing block keeps its
+// duration, and an anchor that does not fit it makes the day infeasible.
+// start must be in [00:00, 24:00), end in [00:00, 24:00] and the two
+// must differ, since a whole day is not an anchor.
+func (b *TimelineBuilder) Anchor(name string, start, end time.Duration) *TimelineBuilder {
+	if start < 0 || start >= dayLength || end < 0 || end > dayLength || start == end%dayLength {
+		b.errs = append(b.errs, fmt.Errorf("anchor of %s: %s-%s is not a time span within a day", name, clock(start), clock(end)))
+		return b
+	}
+	if !b.has(name) {
+		b.AddBlock(name, Exactly(span{start, end}.length()))
+	}
+	b.anchors[name] = span{start, end}
+	return b
+}
+
+// Window keeps name between from and to, on the same day.
+func (b *TimelineBuilder) Window(name string, from, to time.Duration) *TimelineBuilder {
+	if from >= to || from < 0 || to > dayLength {
+		b.errs = append(b.errs, fmt.Errorf("window of %s: %s-%s is not within one day", name, clock(from), clock(to)))
+	}
+	b.windows[name] = span{from, to}
+	return b
+}
+
+// Before makes first end before then starts.
+func (b *TimelineBuilder) Before(first, then string) *TimelineBuilder {
+	b.orders = append(b.orders, [2]string{first, then})
+	return b
+}
+
+func (b *TimelineBuilder) has(name string) bool {
+	return slices.ContainsFunc(b.blocks, func(tb timelineBlock) bool { return tb.name == name })
+}
+
+// Build solves the day. It returns an *InfeasibleError when no layout
+// exists, and ErrSearchLimit when the solver could not tell. A cycle of
+// orderings is reported before searching, with the orderings that form
+// it as the conflicts.
+func (b *TimelineBuilder) Build() (Timeline, error) {
+	errs := append([]error(nil), b.errs...)
+	if b.step <= 0 || dayLength%b.step != 0 {
+		errs = append(errs, fmt.Errorf("step %v does not divide a day", b.step))
+	}
+	for name := range b.windows {
+		if !b.has(name) {
+			errs = append(errs, fmt.Errorf("window of unknown block %q", name))
+		}
+	}
+	for _, o := range b.orders {
+		for _, name := range o {
+			if !b.has(name) {
+				errs = append(errs, fmt.Errorf("ordering of unknown block %q", name))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return Timeline{}, fmt.Errorf("invalid timeline: %w", errors.Join(errs...))
+	}
+
+	cs := b.constraints()
+	if cycle := orderCycle(cs); cycle != nil {
+		conflicts := make([]string, len(cycle))
+		for i, c := range cycle {
+			conflicts[i] = c.String()
+		}
+		return Timeline{}, &InfeasibleError{Conflicts: conflicts, Minimal: true}
+	}
+	entries, status := solve(cs, b.step)
+	switch status {
+	case solved:
+		return Timeline{Step: b.step, Entries: entries}, nil
+	case exhausted:
+		return Timeline{}, ErrSearchLimit
+	}
+	core, minimal := conflicts(cs, b.step)
+	return Timeline{}, &InfeasibleError{Conflicts: core, Minimal: minimal}
+}
+
+// constraints lists everything the day must satisfy. Meals without a
+// window of their own get their share of the waking hours, which run
+// from the end of the sleep anchor to its start.
+func (b *TimelineBuilder) constraints() []constraint {
+	var cs []constraint
+	var meals []string
+	for _, tb := range b.blocks {
+		cs = append(cs, constraint{kind: needs, block: tb.name, dur: tb.dur})
+		if strings.HasPrefix(tb.name, "meal ") {
+			meals = append(meals, tb.name)
+		}
+	}
+	for _, tb := range b.blocks {
+		if a, ok := b.anchors[tb.name]; ok {
+			cs = append(cs, constraint{kind: fixedAt, block: tb.name, span: a})
+		}
+		if w, ok := b.windows[tb.name]; ok {
+			cs = append(cs, constraint{kind: within, block: tb.name, span: w})
+		}
+	}
+
+	awake := span{defaultWakeAt, defaultSleepAt}
+	if sleep, ok := b.anchors["sleep"]; ok {
+		awake = span{sleep.end % dayLength, sleep.start}
+	}
+	var defaulted []string
+	for _, name := range meals {
+		if _, ok := b.windows[name]; !ok {
+			defaulted = append(defaulted, name)
+		}
+	}
+	share := awake.length() / time.Duration(max(len(defaulted), 1))
+	share -= share % b.step
+	for i, name := range defaulted {
+		from := awake.start + time.Duration(i)*share
+		cs = append(cs, constraint{kind: within, block: name, span: dayWindow(from, from+share)})
+	}
+
+	for _, o := range b.orders {
+		cs = append(cs, constraint{kind: before, block: o[0], other: o[1]})
+	}
+	return cs
+}
+
+// dayWindow maps from-to, which may run past 24:00 when the waking hours
+// cross midnight, into one day. A window cannot cross midnight, so one
+// that does keeps its longer side.
+func dayWindow(from, to time.Duration) span {
+	switch {
+	case from >= dayLength:
+		return span{from - dayLength, to - dayLength}
+	case to <= dayLength:
+		return span{from, to}
+	case dayLength-from >= to-dayLength:
+		return span{from, dayLength}
+	}
+	return span{0, to - dayLength}
+}
+
+type constraintKind int
+
+const (
+	needs   constraintKind = iota // O bloco existe e dura dur
+	fixedAt                       // O bloco ocupa span
+	within                        // O bloco fica dentro de span
+	before                        // block termina antes de other começar
+)
+
+type constraint struct {
+	kind         constraintKind
+	block, other string
+	dur          HourRange
+	span         span
+}
+
+func (c constraint) String() string {
+	switch c.kind {
+	case needs:
+		return fmt.Sprintf("%s takes %v", c.block, c.dur)
+	case fixedAt:
+		return fmt.Sprintf("%s fixed at %s-%s", c.block, clock(c.span.start), clock(c.span.end))
+	case within:
+		return fmt.Sprintf("%s between %s and %s", c.block, clock(c.span.start), clock(c.span.end))
+	}
+	return fmt.Sprintf("%s before %s", c.block, c.other)
+}
+
+// conflicts shrinks cs to an infeasible subset by deletion: a constraint
+// is dropped for good when the day stays infeasible without it. Dropping
+// a "needs" removes the block and, with it, whatever else refers to it,
+// so those are dropped next. A constraint whose check hits the search
+// limit is kept, and the result is then not known to be minimal.
+func conflicts(cs []constraint, step time.Duration) (core []string, minimal bool) {
+	kept := slices.Clone(cs)
+	minimal = true
+	for i := 0; i < len(kept); {
+		without := slices.Delete(slices.Clone(kept), i, i+1)
+		switch _, status := solve(without, step); status {
+		case infeasible:
+			kept = without
+			continue
+		case exhausted:
+			minimal = false
+		}
+		i++
+	}
+	core = make([]string, len(kept))
+	for i, c := range kept {
+		core[i] = c.String()
+	}
+	return core, minimal
+}
+
+// orderCycle returns the "before" constraints of a cycle of orderings
+// between existing blocks, or nil. No layout satisfies a cycle, and the
+// search would only find that out by trying every placement.
+func orderCycle(cs []constraint) []constraint {
+	exists := make(map[string]bool)
+	for _, c := range cs {
+		if c.kind == needs {
+			exists[c.block] = true
+		}
+	}
+	edges := make(map[string][]constraint)
+	for _, c := range cs {
+		if c.kind == before && exists[c.block] && exists[c.other] {
+			edges[c.block] = append(edges[c.block], c)
+		}
+	}
+
+	const (
+		unvisited = iota
+		onPath
+		done
+	)
+	state := make(map[string]int)
+	var path []constraint
+	var visit func(name string) []constraint
+	visit = func(name string) []constraint {
+		state[name] = onPath
+		for _, c := range edges[name] {
+			path = append(path, c)
+			switch state[c.other] {
+			case onPath:
+				// O ciclo começa na aresta que saiu de c.other
+				start := slices.IndexFunc(path, func(e constraint) bool { return e.block == c.other })
+				return slices.Clone(path[start:])
+			case unvisited:
+				if cycle := visit(c.other); cycle != nil {
+					return cycle
+				}
+			}
+			path = path[:len(path)-1]
+		}
+		state[name] = done
+		return nil
+	}
+	for _, c := range cs {
+		if c.kind == needs && state[c.block] == unvisited {
+			if cycle := visit(c.block); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+type solveStatus int
+
+const (
+	solved solveStatus = iota
+	infeasible
+	exhausted
+)
+
+// slotBlock is a block on the grid of the solver, in slots of one step.
+type slotBlock struct {
+	name       string
+	seq        int
+	minL, maxL int
+	fixed      bool
+	lo, hi     int // Janela: o bloco fica em [lo, hi)
+	at, length int
+	placed     bool
+}
+
+func (sb *slotBlock) end() int { return sb.at + sb.length }
+
+type solver struct {
+	n      int
+	busy   []bool
+	blocks []*slotBlock // Flexíveis, na ordem da busca
+	orders [][2]*slotBlock
+	work   int
+}
+
+// solve looks for a layout by backtracking over the flexible blocks,
+// tightest window first, trying the longest duration and earliest start
+// first. Before each step it checks that the remaining blocks still fit,
+// which cuts most dead ends early.
+func solve(cs []constraint, step time.Duration) ([]TimelineEntry, solveStatus) {
+	n := int(dayLength / step)
+	byName := make(map[string]*slotBlock)
+	var all []*slotBlock
+	for _, c := range cs {
+		if c.kind == needs {
+			sb := &slotBlock{
+				name: c.block, seq: len(all),
+				minL: ceilDiv(c.dur.Min, step), maxL: int(c.dur.Max / step),
+				lo: 0, hi: n,
+			}
+			sb.maxL = max(sb.maxL, sb.minL)
+			byName[c.block] = sb
+			all = append(all, sb)
+		}
+	}
+
+	sv := &solver{n: n, busy: make([]bool, n)}
+	for _, c := range cs {
+		sb, ok := byName[c.block]
+		if !ok {
+			continue
+		}
+		switch c.kind {
+		case fixedAt:
+			sb.fixed = true
+			sb.at = int(c.span.start / step)
+			sb.length = ceilDiv(c.span.length(), step)
+		case within:
+			sb.lo = max(sb.lo, ceilDiv(c.span.start, step))
+			sb.hi = min(sb.hi, int(c.span.end/step))
+		case before:
+			if other, ok := byName[c.other]; ok {
+				sv.orders = append(sv.orders, [2]*slotBlock{sb, other})
+			}
+		}
+	}
+
+	for _, sb := range all {
+		if !sb.fixed {
+			sv.blocks = append(sv.blocks, sb)
+			continue
+		}
+		// Âncoras podem cruzar a meia-noite, mas não a janela
+		if sb.lo > 0 || sb.hi < n {
+			if sb.at < sb.lo || sb.end() > sb.hi {
+				return nil, infeasible
+			}
+		}
+		if sb.length > n || sb.length < sb.minL || sb.length > sb.maxL {
+			return nil, infeasible
+		}
+		for k := range sb.length {
+			slot := (sb.at + k) % n
+			if sv.busy[slot] {
+				return nil, infeasible
+			}
+			sv.busy[slot] = true
+		}
+		sb.placed = true
+	}
+	if orderCycle(cs) != nil {
+		return nil, infeasible
+	}
+	for _, o := range sv.orders {
+		if o[0].fixed && o[1].fixed && o[0].end() > o[1].at {
+			return nil, infeasible
+		}
+	}
+
+	slices.SortStableFunc(sv.blocks, func(a, b *slotBlock) int {
+		return cmp.Compare((a.hi-a.lo)-a.minL, (b.hi-b.lo)-b.minL)
+	})
+	if !sv.place(0) {
+		if sv.work > maxSearchNodes {
+			return nil, exhausted
+		}
+		return nil, infeasible
+	}
+
+	entries := make([]TimelineEntry, 0, len(all))
+	for _, sb := range all {
+		if sb.length == 0 {
+			continue // Faixa com mínimo zero que ficou de fora
+		}
+		end := sb.end()
+		if end > n {
+			end -= n
+		}
+		entries = append(entries, TimelineEntry{
+			Name:  sb.name,
+			Start: time.Duration(sb.at) * step,
+			End:   time.Duration(end) * step,
+			Fixed: sb.fixed,
+		})
+	}
+	slices.SortFunc(entries, func(a, b TimelineEntry) int { return cmp.Compare(a.Start, b.Start) })
+	return entries, solved
+}
+
+func (sv *solver) place(i int) bool {
+	if i == len(sv.blocks) {
+		return true
+	}
+	if sv.work++; sv.work > maxSearchNodes || !sv.restFits(i) {
+		return false
+	}
+	sb := sv.blocks[i]
+	for length := sb.maxL; length >= sb.minL; length-- {
+		for at := sb.lo; at+length <= sb.hi; at++ {
+			if !sv.free(at, length) || !sv.ordered(sb, at, length) {
+				continue
+			}
+			sv.mark(sb, at, length, true)
+			if sv.place(i + 1) {
+				return true
+			}
+			sv.mark(sb, at, length, false)
+			if sv.work > maxSearchNodes {
+				return false
+			}
+		}
+	}
+	return false
+}
+
+// restFits is the forward check: the free slots cover the minimum of
+// every block still to place, and each of them has room in its window
+// narrowed by the orderings.
+func (sv *solver) restFits(i int) bool {
+	free, need := 0, 0
+	for _, busy := range sv.busy {
+		if !busy {
+			free++
+		}
+	}
+	rest := sv.blocks[i:]
+	lo, hi := sv.bounds(rest)
+	for _, sb := range rest {
+		need += sb.minL
+		fits := false
+		for at := lo[sb]; at+sb.minL <= hi[sb] && !fits; at++ {
+			fits = sv.free(at, sb.minL)
+		}
+		if !fits {
+			return false
+		}
+	}
+	return need <= free
+}
+
+// bounds narrows the window of each unplaced block by the orderings: it
+// cannot start before its predecessors end, nor end after its successors
+// start, counting unplaced neighbours at their minimum length. The
+// orderings are acyclic, so len(rest) rounds of relaxation settle it.
+func (sv *solver) bounds(rest []*slotBlock) (lo, hi map[*slotBlock]int) {
+	lo = make(map[*slotBlock]int, len(rest))
+	hi = make(map[*slotBlock]int, len(rest))
+	for _, sb := range rest {
+		lo[sb], hi[sb] = sb.lo, sb.hi
+	}
+	earliestEnd := func(sb *slotBlock) int {
+		if sb.placed {
+			return sb.end()
+		}
+		return lo[sb] + sb.minL
+	}
+	latestStart := func(sb *slotBlock) int {
+		if sb.placed {
+			return sb.at
+		}
+		return hi[sb] - sb.minL
+	}
+	for range len(rest) + 1 {
+		changed := false
+		for _, o := range sv.orders {
+			first, then := o[0], o[1]
+			if !then.placed {
+				if e := earliestEnd(first); e > lo[then] {
+					lo[then], changed = e, true
+				}
+			}
+			if !first.placed {
+				if s := latestStart(then); s < hi[first] {
+					hi[first], changed = s, true
+				}
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+	return lo, hi
+}
+
+func (sv *solver) free(at, length int) bool {
+	return !slices.Contains(sv.busy[at:at+length], true)
+}
+
+// ordered checks the orderings between sb, placed at at, and the blocks
+// already placed.
+func (sv *solver) ordered(sb *slotBlock, at, length int) bool {
+	for _, o := range sv.orders {
+		switch {
+		case o[0] == sb && o[1].placed && at+length > o[1].at:
+			return false
+		case o[1] == sb && o[0].placed && o[0].end() > at:
+			return false
+		}
+	}
+	return true
+}
+
+func (sv *solver) mark(sb *slotBlock, at, length int, on bool) {
+	for k := at; k < at+length; k++ {
+		sv.busy[k] = on
+	}
+	sb.at, sb.length, sb.placed = at, length, on
+}
+
+func ceilDiv(d, step time.Duration) int {
+	return int((d + step - 1) / step)
+}
+
+// clock formats a time of day as "07:30"; the end of the day is "24:00".
+func clock(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d/time.Hour), int(d%time.Hour/time.Minute))
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"fmt"
+	"hash/fnv"
+	"html"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Timeline is a solved day.
+type Timeline struct {
+	Step    time.Duration
+	Entries []TimelineEntry // Por horário de início
+}
+
+// TimelineEntry is one activity of the day. A fixed entry crossing
+// midnight, like sleep, has End before Start.
+type TimelineEntry struct {
+	Name       string
+	Start, End time.Duration
+	Fixed      bool
+}
+
+func (e TimelineEntry) Duration() time.Duration {
+	return span{e.Start, e.End}.length()
+}
+
+// timelineRun is a stretch of the day from 00:00 to 24:00 with one
+// occupant; entries crossing midnight are split in two runs, and free
+// time is a run with no entry.
+type timelineRun struct {
+	start, end time.Duration
+	entry      *TimelineEntry
+}
+
+func (t Timeline) runs() []timelineRun {
+	var runs []timelineRun
+	for i := range t.Entries {
+		e := &t.Entries[i]
+		if e.End <= e.Start {
+			runs = append(runs, timelineRun{0, e.End, e}, timelineRun{e.Start, dayLength, e})
+			continue
+		}
+		runs = append(runs, timelineRun{e.Start, e.End, e})
+	}
+	slices.SortFunc(runs, func(a, b timelineRun) int { return cmp.Compare(a.start, b.start) })
+
+	var out []timelineRun
+	at := time.Duration(0)
+	for _, r := range runs {
+		if r.start > at {
+			out = append(out, timelineRun{at, r.start, nil})
+		}
+		out = append(out, r)
+		at = r.end
+	}
+	if at < dayLength {
+		out = append(out, timelineRun{at, dayLength, nil})
+	}
+	return out
+}
+
+// Table renders the day from 00:00 to 24:00, free time included.
+func (t Timeline) Table() string {
+	var b strings.Builder
+	tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "START\tEND\tDURATION\tACTIVITY")
+	for _, r := range t.runs() {
+		name := "(free)"
+		if r.entry != nil {
+			name = r.entry.Name
+			if r.entry.Fixed {
+				name += " (fixed)"
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", clock(r.start), clock(r.end), shortDuration(r.end-r.start), name)
+	}
+	tw.Flush()
+	return b.String()
+}
+
+func (t Timeline) String() string { return t.Table() }
+
+// Dimensões da visão SVG: uma coluna com uma faixa por hora.
+const (
+	svgWidth      = 360
+	svgHourHeight = 28
+	svgMargin     = 16
+	svgGutter     = 48
+)
+
+var svgPalette = []string{"#4e79a7", "#f28e2b", "#59a14f", "#e15759", "#76b7b2", "#edc948", "#b07aa1", "#ff9da7", "#9c755f"}
+
+// WriteSVG renders the day as a vertical SVG, midnight at the top, with
+// an hour grid and one labelled box per activity.
+func (t Timeline) WriteSVG(w io.Writer) error {
+	height := 24*svgHourHeight + 2*svgMargin
+	y := func(d time.Duration) float64 {
+		return svgMargin + float64(d)/float64(time.Hour)*svgHourHeight
+	}
+	left, boxWidth := float64(svgGutter), float64(svgWidth-svgGutter-svgMargin)
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="11">`+"\n",
+		svgWidth, height, svgWidth, height)
+	fmt.Fprintf(bw, `<rect width="%d" height="%d" fill="#ffffff"/>`+"\n", svgWidth, height)
+	for h := range 25 {
+		hy := y(time.Duration(h) * time.Hour)
+		fmt.Fprintf(bw, `<line x1="%g" y1="%g" x2="%g" y2="%g" stroke="#dddddd"/>`+"\n", left, hy, left+boxWidth, hy)
+		fmt.Fprintf(bw, `<text x="%g" y="%g" text-anchor="end" dominant-baseline="middle" fill="#666666">%02d:00</text>`+"\n", left-6, hy, h)
+	}
+	for _, r := range t.runs() {
+		if r.entry == nil {
+			continue
+		}
+		top, bottom := y(r.start), y(r.end)
+		fmt.Fprintf(bw, `<rect x="%g" y="%g" width="%g" height="%g" rx="3" fill="%s" fill-opacity="0.85"><title>%s</title></rect>`+"\n",
+			left+1, top+1, boxWidth-2, bottom-top-2, svgColor(r.entry.Name), html.EscapeString(entryLabel(*r.entry)))
+		if bottom-top >= 14 {
+			fmt.Fprintf(bw, `<text x="%g" y="%g" dominant-baseline="middle" fill="#ffffff">%s</text>`+"\n",
+				left+8, (top+bottom)/2, html.EscapeString(entryLabel(*r.entry)))
+		}
+	}
+	fmt.Fprintln(bw, "</svg>")
+	return bw.Flush()
+}
+
+// SaveSVG writes the SVG day view to path.
+func (t Timeline) SaveSVG(path string) error {
+	var buf bytes.Buffer
+	if err := t.WriteSVG(&buf); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+func entryLabel(e TimelineEntry) string {
+	return fmt.Sprintf("%s %s-%s", e.Name, clock(e.Start), clock(e.End))
+}
+
+// svgColor gives each activity a stable color.
+func svgColor(name string) string {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return svgPalette[h.Sum32()%uint32(len(svgPalette))]
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testRoutine(t *testing.T) dailyRoutine {
+	t.Helper()
+	r, err := NewDailyRoutine().SetEat("3 meals").
+		SetFamilyTime(2).
+		SetWork(8).
+		SetSleep("7-8 hours").
+		SetProgramming("1-2 hours").
+		SetExercise(true).
+		SetLanguageStudy(true).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// requireNoOverlap checks that the entries cover disjoint slots of the day.
+func requireNoOverlap(t *testing.T, tl Timeline) {
+	t.Helper()
+	var busy [24 * 60]string
+	for _, e := range tl.Entries {
+		for m := 0; m < int(e.Duration()/time.Minute); m++ {
+			slot := (int(e.Start/time.Minute) + m) % len(busy)
+			if busy[slot] != "" {
+				t.Fatalf("%s overlaps %s at %s\n%s", e.Name, busy[slot], clock(time.Duration(slot)*time.Minute), tl.Table())
+			}
+			busy[slot] = e.Name
+		}
+	}
+}
+
+func entry(tl Timeline, name string) TimelineEntry {
+	i := slices.IndexFunc(tl.Entries, func(e TimelineEntry) bool { return e.Name == name })
+	if i < 0 {
+		return TimelineEntry{}
+	}
+	return tl.Entries[i]
+}
+
+func TestTimelineLayout(t *testing.T) {
+	tl, err := NewTimelineBuilder(testRoutine(t)).
+		Window("work", 9*time.Hour, 18*time.Hour).
+		Window("familyTime", 18*time.Hour, 23*time.Hour).
+		Before("exercise", "work").
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	requireNoOverlap(t, tl)
+
+	sleep := entry(tl, "sleep")
+	if !sleep.Fixed || sleep.Start != 23*time.Hour || sleep.End != 7*time.Hour {
+		t.Errorf("sleep = %+v, want fixed 23:00-07:00", sleep)
+	}
+	work := entry(tl, "work")
+	if work.Start < 9*time.Hour || work.End > 18*time.Hour || work.Duration() != 8*time.Hour {
+		t.Errorf("work = %+v, want 8h within 09:00-18:00", work)
+	}
+	if ex := entry(tl, "exercise"); ex.End > work.Start {
+		t.Errorf("exercise %+v does not end before work %+v", ex, work)
+	}
+	if p := entry(tl, "programming").Duration(); p != 2*time.Hour {
+		t.Errorf("programming = %v, want the maximum of 1-2 hours", p)
+	}
+	if !strings.Contains(tl.Table(), "(free)") {
+		t.Errorf("table without free time:\n%s", tl.Table())
+	}
+
+	var svg strings.Builder
+	if err := tl.WriteSVG(&svg); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(svg.String(), "<svg") || !strings.Contains(svg.String(), "work 09:00") {
+		t.Errorf("unexpected SVG:\n%s", svg.String())
+	}
+}
+
+func TestTimelineInfeasible(t *testing.T) {
+	r := testRoutine(t)
+	tests := []struct {
+		name  string
+		build *TimelineBuilder
+		want  []string
+	}{
+		{
+			name: "shared window",
+			build: NewTimelineBuilder(r).
+				Window("familyTime", 18*time.Hour, 20*time.Hour).
+				Window("programming", 18*time.Hour, 20*time.Hour),
+			want: []string{
+				"programming takes 1-2 hours",
+				"familyTime takes 2 hours",
+				"programming between 18:00 and 20:00",
+				"familyTime between 18:00 and 20:00",
+			},
+		},
+		{
+			name:  "window inside sleep",
+			build: NewTimelineBuilder(r).Window("work", 0, 9*time.Hour),
+			want:  []string{"sleep takes 7-8 hours", "work takes 8 hours", "sleep fixed at 23:00-07:00", "work between 00:00 and 09:00"},
+		},
+		{
+			name: "cycle",
+			build: NewTimelineBuilder(r).
+				Before("work", "programming").
+				Before("programming", "familyTime").
+				Before("familyTime", "work"),
+			want: []string{"work before programming", "programming before familyTime", "familyTime before work"},
+		},
+		{
+			name:  "self ordering",
+			build: NewTimelineBuilder(r).Before("work", "work"),
+			want:  []string{"work before work"},
+		},
+		{
+			name:  "anchor shorter than sleep",
+			build: NewTimelineBuilder(r).Anchor("sleep", 22*time.Hour, 24*time.Hour),
+			want:  []string{"sleep takes 7-8 hours", "sleep fixed at 22:00-24:00"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, step := range []time.Duration{15 * time.Minute, 5 * time.Minute} {
+				start := time.Now()
+				_, err := tt.build.SetStep(step).Build()
+				var ie *InfeasibleError
+				if !errors.As(err, &ie) {
+					t.Fatalf("step %v: err = %v, want *InfeasibleError", step, err)
+				}
+				if !ie.Minimal || !slices.Equal(ie.Conflicts, tt.want) {
+					t.Errorf("step %v: conflicts = %q (minimal %t), want %q", step, ie.Conflicts, ie.Minimal, tt.want)
+				}
+				if d := time.Since(start); d > 2*time.Second {
+					t.Errorf("step %v: took %v", step, d)
+				}
+			}
+		})
+	}
+}
+
+func TestTimelineOrderingChain(t *testing.T) {
+	// Uma cadeia longa sem ciclo cabe e respeita a ordem
+	tl, err := NewTimelineBuilder(testRoutine(t)).
+		SetStep(5*time.Minute).
+		Before("languageStudy", "exercise").
+		Before("exercise", "work").
+		Before("work", "programming").
+		Before("programming", "familyTime").
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	requireNoOverlap(t, tl)
+	order := []string{"languageStudy", "exercise", "work", "programming", "familyTime"}
+	for i := 1; i < len(order); i++ {
+		if entry(tl, order[i-1]).End > entry(tl, order[i]).Start {
+			t.Errorf("%s does not end before %s:\n%s", order[i-1], order[i], tl.Table())
+		}
+	}
+}
+
+func TestTimelineInvalid(t *testing.T) {
+	r := testRoutine(t)
+	tests := []struct {
+		name  string
+		build *TimelineBuilder
+		want  string
+	}{
+		{"empty anchor", NewTimelineBuilder(r).Anchor("commute", 8*time.Hour, 8*time.Hour), "anchor of commute"},
+		{"anchor after midnight", NewTimelineBuilder(r).Anchor("commute", 25*time.Hour, 26*time.Hour), "anchor of commute"},
+		{"negative anchor", NewTimelineBuilder(r).Anchor("commute", -time.Hour, time.Hour), "anchor of commute"},
+		{"reversed window", NewTimelineBuilder(r).Window("work", 18*time.Hour, 9*time.Hour), "window of work"},
+		{"unknown window", NewTimelineBuilder(r).Window("nap", 13*time.Hour, 14*time.Hour), `window of unknown block "nap"`},
+		{"unknown ordering", NewTimelineBuilder(r).Before("nap", "work"), `ordering of unknown block "nap"`},
+		{"step", NewTimelineBuilder(r).SetStep(7 * time.Minute), "does not divide a day"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.build.Build()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimelineAnchorAtMidnight(t *testing.T) {
+	tl, err := NewTimelineBuilder(testRoutine(t)).
+		Anchor("commute", 15*time.Hour+30*time.Minute, 16*time.Hour).
+		Anchor("sleep", 16*time.Hour, 24*time.Hour).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	requireNoOverlap(t, tl)
+	if c := entry(tl, "commute"); !c.Fixed || c.Start != 15*time.Hour+30*time.Minute || c.Duration() != 30*time.Minute {
+		t.Errorf("commute = %+v", c)
+	}
+	if s := entry(tl, "sleep"); s.Start != 16*time.Hour || s.Duration() != 8*time.Hour {
+		t.Errorf("sleep = %+v, want 16:00-24:00", s)
+	}
+	// As refeições seguem as horas acordado da âncora, 00:00-16:00
+	for _, name := range []string{"meal 1", "meal 2", "meal 3"} {
+		if m := entry(tl, name); m.End > 16*time.Hour {
+			t.Errorf("%s = %+v, want it before 16:00", name, m)
+		}
+	}
+}
+
+func TestTimelineSleepFromRoutine(t *testing.T) {
+	tests := []struct {
+		sleep string
+		start time.Duration
+	}{
+		{"6 hours", time.Hour},
+		{"7-8 hours", 23 * time.Hour},
+		{"10 hours", 21 * time.Hour},
+	}
+	for _, tt := range tests {
+		r, err := NewDailyRoutine().SetEat("3 meals").SetWork(8).SetSleep(tt.sleep).Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		tl, err := NewTimelineBuilder(r).Build()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.sleep, err)
+		}
+		requireNoOverlap(t, tl)
+		s := entry(tl, "sleep")
+		if !s.Fixed || s.Start != tt.start || s.End != 7*time.Hour {
+			t.Errorf("%s: sleep = %+v, want %s-07:00", tt.sleep, s, clock(tt.start))
+		}
+		for _, name := range []string{"meal 1", "meal 2", "meal 3"} {
+			m := entry(tl, name)
+			if awake := m.Start >= 7*time.Hour && (tt.start < 7*time.Hour || m.End <= tt.start); !awake {
+				t.Errorf("%s: %s = %+v, want it in the waking hours", tt.sleep, name, m)
+			}
+		}
+	}
+}
+
+func TestTimelineDaySleeper(t *testing.T) {
+	r, err := NewDailyRoutine().SetEat("3 meals").SetWork(8).SetSleep("8 hours").Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Acordado das 21:00 às 13:00: a fatia de refeição que cruza a
+	// meia-noite fica com o lado maior
+	tl, err := NewTimelineBuilder(r).Anchor("sleep", 13*time.Hour, 21*time.Hour).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	requireNoOverlap(t, tl)
+	for _, name := range []string{"meal 1", "meal 2", "meal 3"} {
+		if m := entry(tl, name); m.Start < 21*time.Hour && m.End > 13*time.Hour {
+			t.Errorf("%s = %+v, want it outside 13:00-21:00\n%s", name, m, tl.Table())
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HourRange is an amount of time in a routine that may vary between Min
+// and Max, like "7-8 hours" of sleep. An exact amount has Min == Max; the
+// zero value means "not set".
+type HourRange struct {
+	Min, Max time.Duration
+}
+
+// Exactly is a range of exactly d.
+func Exactly(d time.Duration) HourRange { return HourRange{d, d} }
+
+// Between is a range from min to max.
+func Between(min, max time.Duration) HourRange { return HourRange{min, max} }
+
+// Hours is a range of exactly h whole hours, for the int setters.
+func Hours(h int) HourRange { return Exactly(time.Duration(h) * time.Hour) }
+
+func (r HourRange) IsZero() bool { return r == HourRange{} }
+
+func (r HourRange) Add(o HourRange) HourRange {
+	return HourRange{r.Min + o.Min, r.Max + o.Max}
+}
+
+// String formats r so that ParseHourRange reads it back: "8 hours",
+// "7-8 hours" or, when not whole hours, "2h30m" and "1h30m-2h".
+func (r HourRange) String() string {
+	if r.Min%time.Hour == 0 && r.Max%time.Hour == 0 {
+		lo, hi := int64(r.Min/time.Hour), int64(r.Max/time.Hour)
+		switch {
+		case lo != hi:
+			return fmt.Sprintf("%d-%d hours", lo, hi)
+		case lo == 1:
+			return "1 hour"
+		}
+		return fmt.Sprintf("%d hours", lo)
+	}
+	if r.Min == r.Max {
+		return shortDuration(r.Min)
+	}
+	return shortDuration(r.Min) + "-" + shortDuration(r.Max)
+}
+
+// shortDuration is time.Duration.String without the trailing zero units:
+// "2h30m" instead of "2h30m0s".
+func shortDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = s[:len(s)-2]
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = s[:len(s)-2]
+	}
+	return s
+}
+
+var timeUnits = map[string]time.Duration{
+	"h": time.Hour, "hr": time.Hour, "hrs": time.Hour, "hour": time.Hour, "hours": time.Hour,
+	"m": time.Minute, "min": time.Minute, "mins": time.Minute, "minute": time.Minute, "minutes": time.Minute,
+}
+
+// ParseHourRange reads the forms people write in a routine: "8 hours",
+// "1 hour", "1.5 hours", "45 minutes", "7-8 hours", "7-8h" and Go
+// durations such as "2h30m" or "1h30m-2h".
+func ParseHourRange(s string) (HourRange, error) {
+	text := strings.ToLower(strings.TrimSpace(s))
+	lo, hi, isRange := strings.Cut(text, "-")
+	if !isRange {
+		hi = lo
+	}
+	// A unidade pode vir só no fim: "7-8 hours" vale para os dois lados
+	fail := func(err error) (HourRange, error) {
+		if errors.Is(err, errAmountSyntax) {
+			return HourRange{}, fmt.Errorf("invalid hours %q (want e.g. \"8 hours\", \"7-8 hours\" or \"2h30m\")", s)
+		}
+		return HourRange{}, fmt.Errorf("invalid hours %q: %w", s, err)
+	}
+	max, maxUnit, err := parseAmount(hi, 0)
+	if err != nil {
+		return fail(err)
+	}
+	min := max
+	if isRange {
+		if min, _, err = parseAmount(lo, maxUnit); err != nil {
+			return fail(err)
+		}
+	}
+	if min > max {
+		return HourRange{}, fmt.Errorf("invalid hours %q: minimum above maximum", s)
+	}
+	return HourRange{min, max}, nil
+}
+
+// errAmountSyntax is returned by parseAmount for text that is not an
+// amount at all, errAmountRange for a number a Duration cannot hold.
+var (
+	errAmountSyntax = errors.New("not an amount of time")
+	errAmountRange  = fmt.Errorf("out of range (at most %dh)", math.MaxInt64/int64(time.Hour))
+)
+
+// parseAmount reads "8", "8 hours", "8h" or "2h30m". A bare number takes
+// defaultUnit, and fails without one; the unit found, if any, is returned
+// for the other side of a range.
+func parseAmount(s string, defaultUnit time.Duration) (d, unit time.Duration, err error) {
+	s = strings.TrimSpace(s)
+	if n, err := parseNumber(s); !errors.Is(err, errAmountSyntax) {
+		if err == nil && defaultUnit == 0 {
+			err = errAmountSyntax
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+		d, err := amountOf(n, defaultUnit)
+		return d, 0, err
+	}
+	if value, word, found := strings.Cut(s, " "); found {
+		unit, known := timeUnits[strings.TrimSpace(word)]
+		if !known {
+			return 0, 0, errAmountSyntax
+		}
+		n, err := parseNumber(value)
+		if err != nil {
+			return 0, 0, err
+		}
+		d, err := amountOf(n, unit)
+		return d, unit, err
+	}
+	d, err = time.ParseDuration(s)
+	if err != nil {
+		return 0, 0, errAmountSyntax
+	}
+	unit = time.Hour
+	switch {
+	case strings.HasSuffix(s, "ms"):
+		unit = time.Millisecond
+	case strings.HasSuffix(s, "m"):
+		unit = time.Minute
+	case strings.HasSuffix(s, "s"):
+		unit = time.Second
+	}
+	return d, unit, nil
+}
+
+// parseNumber is strconv.ParseFloat without the values that are not an
+// amount: it accepts "NaN" and "Inf", and 1e400 overflows to Inf.
+func parseNumber(s string) (float64, error) {
+	n, err := strconv.ParseFloat(s, 64)
+	switch {
+	case errors.Is(err, strconv.ErrRange):
+		return 0, errAmountRange
+	case err != nil:
+		return 0, errAmountSyntax
+	case math.IsNaN(n) || math.IsInf(n, 0):
+		return 0, fmt.Errorf("%s is not a finite number", s)
+	}
+	return n, nil
+}
+
+// amountOf converts n units to a Duration, failing when the result does
+// not fit in one (about 2.5 million hours).
+func amountOf(n float64, unit time.Duration) (time.Duration, error) {
+	d := n * float64(unit)
+	if d >= math.MaxInt64 {
+		return 0, errAmountRange
+	}
+	return time.Duration(d), nil
+}
+
+// Meals is the number of meals in a day.
+type Meals int
+
+func (m Meals) String() string {
+	if m == 1 {
+		return "1 meal"
+	}
+	return fmt.Sprintf("%d meals", int(m))
+}
+
+// ParseMeals reads "3 meals", "1 meal" or a bare "3".
+func ParseMeals(s string) (Meals, error) {
+	value, unit, hasUnit := strings.Cut(strings.TrimSpace(s), " ")
+	n, err := strconv.Atoi(value)
+	if err != nil || hasUnit && unit != "meal" && unit != "meals" {
+		return 0, fmt.Errorf("%q is not a number of meals (want \"N meals\")", s)
+	}
+	return Meals(n), nil
+}
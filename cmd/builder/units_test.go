@@ -0,0 +1,89 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseHourRange(t *testing.T) {
+	tests := []struct {
+		in   string
+		want HourRange
+	}{
+		{"8 hours", Hours(8)},
+		{"1 hour", Hours(1)},
+		{"1.5 hours", Exactly(90 * time.Minute)},
+		{"45 minutes", Exactly(45 * time.Minute)},
+		{"7-8 hours", Between(7*time.Hour, 8*time.Hour)},
+		{"7-8h", Between(7*time.Hour, 8*time.Hour)},
+		{" 7 - 8 HOURS ", Between(7*time.Hour, 8*time.Hour)},
+		{"2h30m", Exactly(150 * time.Minute)},
+		{"1h30m-2h", Between(90*time.Minute, 2*time.Hour)},
+		{"30-90 minutes", Between(30*time.Minute, 90*time.Minute)},
+	}
+	for _, tt := range tests {
+		got, err := ParseHourRange(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseHourRange(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+		// String volta a um texto que ParseHourRange lê igual
+		if back, err := ParseHourRange(got.String()); err != nil || back != got {
+			t.Errorf("ParseHourRange(%q) = %v, %v; want %v", got.String(), back, err, got)
+		}
+	}
+}
+
+func TestParseHourRangeErrors(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"8", "want e.g."},
+		{"", "want e.g."},
+		{"8 days", "want e.g."},
+		{"lots", "want e.g."},
+		{"7-", "want e.g."},
+		{"9-8 hours", "minimum above maximum"},
+		{"NaN hours", "not a finite number"},
+		{"Inf hours", "not a finite number"},
+		{"nan-8 hours", "not a finite number"},
+		{"1-inf hours", "not a finite number"},
+		{"1e300 hours", "out of range"},
+		{"1e400 hours", "out of range"},
+		{"1e300-2e300 hours", "out of range"},
+		{"3000000 hours", "out of range"},
+	}
+	for _, tt := range tests {
+		_, err := ParseHourRange(tt.in)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseHourRange(%q) err = %v, want it to mention %q", tt.in, err, tt.want)
+		}
+		if err != nil && tt.want != "minimum above maximum" && strings.Contains(err.Error(), "minimum above maximum") {
+			t.Errorf("ParseHourRange(%q) blames the bounds: %v", tt.in, err)
+		}
+	}
+}
+
+func TestParseMeals(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Meals
+		ok   bool
+	}{
+		{"3 meals", 3, true},
+		{"1 meal", 1, true},
+		{" 4 ", 4, true},
+		{"3 snacks", 0, false},
+		{"three meals", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseMeals(tt.in)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("ParseMeals(%q) = %v, %v; want %v (ok %t)", tt.in, got, err, tt.want, tt.ok)
+		}
+	}
+	if s := Meals(1).String(); s != "1 meal" {
+		t.Errorf("Meals(1) = %q", s)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// FieldError is one rule violated by a dailyRoutine.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e FieldError) Error() string { return e.Field + ": " + e.Message }
+
+// ValidationError collects every violation found by Build, so the caller
+// can fix them all at once instead of one per attempt.
+type ValidationError struct {
+	Violations []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msgs[i] = v.Error()
+	}
+	return fmt.Sprintf("invalid daily routine (%d violations): %s", len(e.Violations), strings.Join(msgs, "; "))
+}
+
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Violations))
+	for i, v := range e.Violations {
+		errs[i] = v
+	}
+	return errs
+}
+
+// rule checks one aspect of a routine and returns its violations.
+type rule func(r dailyRoutine) []FieldError
+
+// dailyRoutineRules are run in order by Build; all of them always run.
+var dailyRoutineRules = []rule{
+	required("sleep", func(r dailyRoutine) bool { return !r.sleep.IsZero() }),
+	required("eat", func(r dailyRoutine) bool { return r.eat != 0 }),
+	hoursInRange("familyTime", 0, 24*time.Hour, func(r dailyRoutine) HourRange { return r.familyTime }),
+	hoursInRange("work", 0, 24*time.Hour, func(r dailyRoutine) HourRange { return r.work }),
+	hoursInRange("sleep", 0, 24*time.Hour, func(r dailyRoutine) HourRange { return r.sleep }),
+	hoursInRange("programming", 0, 24*time.Hour, func(r dailyRoutine) HourRange { return r.programming }),
+	mealsInRange("eat", 1, 8, func(r dailyRoutine) Meals { return r.eat }),
+	totalHoursAtMost(24 * time.Hour),
+}
+
+// validate runs every rule. Fields whose string did not parse are
+// reported once, by parseErrors, and skipped by the rules.
+func validate(r dailyRoutine, parseErrors []FieldError) error {
+	violations := append([]FieldError(nil), parseErrors...)
+	for _, check := range dailyRoutineRules {
+		for _, v := range check(r) {
+			if !slices.ContainsFunc(parseErrors, func(e FieldError) bool { return e.Field == v.Field }) {
+				violations = append(violations, v)
+			}
+		}
+	}
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+	return nil
+}
+
+func required(field string, isSet func(dailyRoutine) bool) rule {
+	return func(r dailyRoutine) []FieldError {
+		if !isSet(r) {
+			return []FieldError{{field, "is required"}}
+		}
+		return nil
+	}
+}
+
+func hoursInRange(field string, lo, hi time.Duration, get func(dailyRoutine) HourRange) rule {
+	return func(r dailyRoutine) []FieldError {
+		v := get(r)
+		if v.Min > v.Max {
+			return []FieldError{{field, fmt.Sprintf("minimum %v is above maximum %v", v.Min, v.Max)}}
+		}
+		if v.Min < lo || v.Max > hi {
+			return []FieldError{{field, fmt.Sprintf("%v is out of range [%v, %v]", v, lo, hi)}}
+		}
+		return nil
+	}
+}
+
+func mealsInRange(field string, lo, hi Meals, get func(dailyRoutine) Meals) rule {
+	return func(r dailyRoutine) []FieldError {
+		// Zero é "não definido", assunto de required
+		if n := get(r); n != 0 && (n < lo || n > hi) {
+			return []FieldError{{field, fmt.Sprintf("%v is out of range [%d, %d]", n, lo, hi)}}
+		}
+		return nil
+	}
+}
+
+// totalHoursAtMost checks that the scheduled hours fit in a day. Ranges
+// count with their minimum: the routine is rejected only when it cannot
+// fit at all.
+func totalHoursAtMost(limit time.Duration) rule {
+	return func(r dailyRoutine) []FieldError {
+		if total := r.TotalHours().Min; total > limit {
+			return []FieldError{{"total", fmt.Sprintf("%v scheduled exceeds %v", shortDuration(total), shortDuration(limit))}}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildValidation(t *testing.T) {
+	valid := func() *DailyRoutineBuilder {
+		return NewDailyRoutine().SetEat("3 meals").SetSleep("8 hours")
+	}
+	tests := []struct {
+		name   string
+		build  *DailyRoutineBuilder
+		fields []string // Campos violados, na ordem das regras
+	}{
+		{"valid", valid().SetWork(8).SetFamilyTime(2).SetProgramming("1-2 hours"), nil},
+		{"exactly a full day", valid().SetWork(16), nil},
+		{"empty", NewDailyRoutine(), []string{"sleep", "eat"}},
+		{"negative work", valid().SetWork(-1), []string{"work"}},
+		{"work above a day", valid().SetWork(25), []string{"work", "total"}},
+		{"reversed range", valid().SetSleepRange(Between(8*time.Hour, 7*time.Hour)), []string{"sleep"}},
+		{"too many meals", valid().SetMeals(9), []string{"eat"}},
+		{"zero meals", valid().SetMeals(0), []string{"eat"}},
+		{"over a day", valid().SetWork(10).SetFamilyTime(4).SetProgramming("3 hours"), []string{"total"}},
+		{"range fits at its minimum", valid().SetWork(10).SetFamilyTime(4).SetProgramming("2-3 hours"), nil},
+		{"parse errors", NewDailyRoutine().SetSleep("lots").SetEat("three"), []string{"sleep", "eat"}},
+		{"parse error then fixed", valid().SetSleep("lots").SetSleepRange(Hours(7)), nil},
+		{"parse error then fixed by string", valid().SetProgramming("lots").SetProgramming("1 hour"), nil},
+		{"everything at once", NewDailyRoutine().SetEat("12").SetWork(-2).SetFamilyTime(30), []string{"sleep", "familyTime", "work", "eat", "total"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.build.Build()
+			if tt.fields == nil {
+				if err != nil {
+					t.Fatalf("Build = %v", err)
+				}
+				return
+			}
+			var ve *ValidationError
+			if !errors.As(err, &ve) {
+				t.Fatalf("Build = %v, want *ValidationError", err)
+			}
+			var fields []string
+			for _, v := range ve.Violations {
+				fields = append(fields, v.Field)
+			}
+			if !slices.Equal(fields, tt.fields) {
+				t.Errorf("violations %q, want fields %q", ve.Violations, tt.fields)
+			}
+		})
+	}
+}
+
+func TestValidationErrorReporting(t *testing.T) {
+	_, err := NewDailyRoutine().SetSleep("lots").SetMeals(9).Build()
+	var ve *ValidationError
+	if !errors.As(err, &ve) || len(ve.Violations) != 2 {
+		t.Fatalf("Build = %v, want two violations", err)
+	}
+	// Um campo que não parseou aparece uma vez, com o erro do parser
+	if msg := err.Error(); !strings.HasPrefix(msg, "invalid daily routine (2 violations): sleep: invalid hours \"lots\"") ||
+		strings.Contains(msg, "sleep: is required") {
+		t.Errorf("message = %q", msg)
+	}
+
+	var fe FieldError
+	if !errors.As(err, &fe) || fe.Field != "sleep" {
+		t.Errorf("errors.As FieldError = %+v, want the sleep violation", fe)
+	}
+	if !errors.Is(err, ve.Violations[1]) {
+		t.Error("errors.Is does not reach the eat violation")
+	}
+}
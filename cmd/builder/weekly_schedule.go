@@ -0,0 +1,203 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Templates accepted by WeeklyScheduleBuilder.SetTemplate.
+const (
+	TemplateWeekday = "weekday" // segunda a sexta
+	TemplateWeekend = "weekend" // sábado e domingo
+)
+
+var templateDays = map[string][]time.Weekday{
+	TemplateWeekday: {time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+	TemplateWeekend: {time.Saturday, time.Sunday},
+}
+
+// Default session lengths counted for the exercise and languageStudy
+// flags, which have no hours of their own.
+const (
+	defaultExerciseSession      = time.Hour
+	defaultLanguageStudySession = 30 * time.Minute
+)
+
+// weekOrder lists the days Monday first, the order schedules are shown in.
+var weekOrder = [7]time.Weekday{
+	time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday,
+}
+
+type weeklySchedule struct {
+	days                 [7]dailyRoutine // Indexado por time.Weekday
+	exerciseSession      time.Duration
+	languageStudySession time.Duration
+}
+
+// Day returns the routine of day.
+func (s weeklySchedule) Day(day time.Weekday) dailyRoutine { return s.days[day] }
+
+// WeeklyTotals are the hours a schedule adds up to in a week.
+type WeeklyTotals struct {
+	Work     HourRange
+	Exercise HourRange
+	Study    HourRange // Programação mais estudo de idiomas
+}
+
+// Totals sums the week. Exercise and language study count one session
+// per day they are enabled.
+func (s weeklySchedule) Totals() WeeklyTotals {
+	var t WeeklyTotals
+	for _, r := range s.days {
+		t.Work = t.Work.Add(r.work)
+		t.Study = t.Study.Add(r.programming)
+		if r.exercise {
+			t.Exercise = t.Exercise.Add(Exactly(s.exerciseSession))
+		}
+		if r.languageStudy {
+			t.Study = t.Study.Add(Exactly(s.languageStudySession))
+		}
+	}
+	return t
+}
+
+// dayTotal is the minimum time the activities of r take, counting the
+// exercise and language study sessions that validation of a single
+// routine does not know about.
+func (s weeklySchedule) dayTotal(r dailyRoutine) time.Duration {
+	total := r.TotalHours().Min
+	if r.exercise {
+		total += s.exerciseSession
+	}
+	if r.languageStudy {
+		total += s.languageStudySession
+	}
+	return total
+}
+
+func (s weeklySchedule) String() string {
+	var b strings.Builder
+	for _, day := range weekOrder {
+		fmt.Fprintf(&b, "%-9s %v\n", day, s.days[day])
+	}
+	t := s.Totals()
+	fmt.Fprintf(&b, "total: work %v, exercise %v, study %v", t.Work, t.Exercise, t.Study)
+	return b.String()
+}
+
+// WeeklyScheduleBuilder assigns a routine to each day of the week.
+// Templates fill groups of days, SetDay replaces the routine of one day
+// and OverrideDay edits it; whatever the call order, a day takes its
+// template, then its SetDay, then its overrides.
+type WeeklyScheduleBuilder struct {
+	templates map[string]dailyRoutine
+	days      map[time.Weekday]dailyRoutine
+	overrides map[time.Weekday][]func(*DailyRoutineBuilder)
+	schedule  weeklySchedule
+	errs      []error
+}
+
+func NewWeeklyScheduleBuilder() *WeeklyScheduleBuilder {
+	return &WeeklyScheduleBuilder{
+		templates: make(map[string]dailyRoutine),
+		days:      make(map[time.Weekday]dailyRoutine),
+		overrides: make(map[time.Weekday][]func(*DailyRoutineBuilder)),
+		schedule: weeklySchedule{
+			exerciseSession:      defaultExerciseSession,
+			languageStudySession: defaultLanguageStudySession,
+		},
+	}
+}
+
+// SetTemplate uses r for the days of template name, TemplateWeekday or
+// TemplateWeekend. An unknown name is reported by Build.
+func (b *WeeklyScheduleBuilder) SetTemplate(name string, r dailyRoutine) *WeeklyScheduleBuilder {
+	if _, ok := templateDays[name]; !ok {
+		b.errs = append(b.errs, fmt.Errorf("unknown template %q (want %q or %q)", name, TemplateWeekday, TemplateWeekend))
+		return b
+	}
+	b.templates[name] = r
+	return b
+}
+
+// SetDay uses r for day instead of its template.
+func (b *WeeklyScheduleBuilder) SetDay(day time.Weekday, r dailyRoutine) *WeeklyScheduleBuilder {
+	b.days[day] = r
+	return b
+}
+
+// OverrideDay edits the routine of day, for changes like a shorter
+// Friday:
+//
+//	OverrideDay(time.Friday, func(r *DailyRoutineBuilder) { r.SetWork(6) })
+//
+// The edited routine is validated again by Build.
+func (b *WeeklyScheduleBuilder) OverrideDay(day time.Weekday, edit func(*DailyRoutineBuilder)) *WeeklyScheduleBuilder {
+	b.overrides[day] = append(b.overrides[day], edit)
+	return b
+}
+
+// SetSessions sets how long an exercise and a language study session
+// last in the totals and the calendar. The defaults are 1h and 30m.
+func (b *WeeklyScheduleBuilder) SetSessions(exercise, languageStudy time.Duration) *WeeklyScheduleBuilder {
+	b.schedule.exerciseSession = exercise
+	b.schedule.languageStudySession = languageStudy
+	return b
+}
+
+// Build resolves every day, or returns all the problems found: unknown
+// templates, days without a routine, overrides that break a rule and days
+// that no longer fit in 24 hours once the sessions are counted.
+func (b *WeeklyScheduleBuilder) Build() (weeklySchedule, error) {
+	errs := append([]error(nil), b.errs...)
+	var missing []string
+	s := b.schedule
+	for _, day := range weekOrder {
+		r, ok := b.routineFor(day)
+		if !ok {
+			missing = append(missing, day.String())
+			continue
+		}
+		if edits := b.overrides[day]; len(edits) > 0 {
+			rb := &DailyRoutineBuilder{dailyRoutine: r}
+			for _, edit := range edits {
+				edit(rb)
+			}
+			var err error
+			if r, err = rb.Build(); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", day, err))
+				continue
+			}
+		}
+		if total := s.dayTotal(r); total > dayLength {
+			errs = append(errs, fmt.Errorf("%s: %s of activities with sessions do not fit in a day", day, shortDuration(total)))
+			continue
+		}
+		s.days[day] = r
+	}
+	if len(missing) > 0 {
+		errs = append(errs, fmt.Errorf("no routine for %s", strings.Join(missing, ", ")))
+	}
+	if b.schedule.exerciseSession < 0 || b.schedule.languageStudySession < 0 {
+		errs = append(errs, errors.New("session lengths must not be negative"))
+	}
+	if len(errs) > 0 {
+		return weeklySchedule{}, fmt.Errorf("invalid weekly schedule: %w", errors.Join(errs...))
+	}
+	return s, nil
+}
+
+func (b *WeeklyScheduleBuilder) routineFor(day time.Weekday) (dailyRoutine, bool) {
+	if r, ok := b.days[day]; ok {
+		return r, true
+	}
+	for name, days := range templateDays {
+		if r, ok := b.templates[name]; ok && slices.Contains(days, day) {
+			return r, true
+		}
+	}
+	return dailyRoutine{}, false
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func mustRoutine(t *testing.T, b *DailyRoutineBuilder) dailyRoutine {
+	t.Helper()
+	r, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func testWeek(t *testing.T) (workday, weekend dailyRoutine) {
+	t.Helper()
+	workday = mustRoutine(t, NewDailyRoutine().SetEat("3 meals").SetWork(8).SetSleep("7-8 hours").
+		SetProgramming("1 hour").SetFamilyTime(2).SetExercise(true).SetLanguageStudy(true))
+	weekend = mustRoutine(t, NewDailyRoutine().SetEat("2 meals").SetSleep("9 hours").SetFamilyTime(4))
+	return workday, weekend
+}
+
+func TestWeeklyScheduleTemplatesAndOverrides(t *testing.T) {
+	workday, weekend := testWeek(t)
+	// Overrides valem sobre o modelo mesmo quando chamados antes dele
+	s, err := NewWeeklyScheduleBuilder().
+		OverrideDay(time.Friday, func(r *DailyRoutineBuilder) { r.SetWork(6).SetExercise(false) }).
+		SetTemplate(TemplateWeekday, workday).
+		SetTemplate(TemplateWeekend, weekend).
+		SetDay(time.Sunday, workday).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := s.Day(time.Monday); got != workday {
+		t.Errorf("Monday = %v, want the weekday template", got)
+	}
+	if got := s.Day(time.Saturday); got != weekend {
+		t.Errorf("Saturday = %v, want the weekend template", got)
+	}
+	if got := s.Day(time.Sunday); got != workday {
+		t.Errorf("Sunday = %v, want the SetDay routine", got)
+	}
+	if fri := s.Day(time.Friday); fri.work != Hours(6) || fri.exercise {
+		t.Errorf("Friday = %v, want the override applied", fri)
+	}
+
+	// Seg-qui e domingo: 8h; sexta: 6h
+	want := WeeklyTotals{
+		Work:     Hours(5*8 + 6),
+		Exercise: Hours(5),
+		Study:    Exactly(6*time.Hour + 6*defaultLanguageStudySession),
+	}
+	if got := s.Totals(); got != want {
+		t.Errorf("Totals() = %+v, want %+v", got, want)
+	}
+}
+
+func TestWeeklyScheduleBuildErrors(t *testing.T) {
+	workday, _ := testWeek(t)
+	overflow := mustRoutine(t, NewDailyRoutine().SetEat("3 meals").SetWork(16).SetProgramming("7 hours").
+		SetSleep("1 hour").SetExercise(true).SetLanguageStudy(true))
+
+	tests := []struct {
+		name  string
+		build *WeeklyScheduleBuilder
+		want  []string
+	}{
+		{
+			name:  "missing days",
+			build: NewWeeklyScheduleBuilder().SetTemplate(TemplateWeekday, workday),
+			want:  []string{"no routine for Saturday, Sunday"},
+		},
+		{
+			name:  "unknown template",
+			build: NewWeeklyScheduleBuilder().SetTemplate("weekdays", workday).SetTemplate(TemplateWeekend, workday),
+			want:  []string{`unknown template "weekdays"`, "no routine for Monday"},
+		},
+		{
+			name: "invalid override",
+			build: NewWeeklyScheduleBuilder().SetTemplate(TemplateWeekday, workday).SetTemplate(TemplateWeekend, workday).
+				OverrideDay(time.Monday, func(r *DailyRoutineBuilder) { r.SetWork(30) }),
+			want: []string{"Monday: invalid daily routine", "work: 30 hours is out of range"},
+		},
+		{
+			name: "sessions overflow the day",
+			build: NewWeeklyScheduleBuilder().SetTemplate(TemplateWeekday, workday).SetTemplate(TemplateWeekend, workday).
+				SetDay(time.Tuesday, overflow),
+			want: []string{"Tuesday: 25h30m of activities with sessions do not fit in a day"},
+		},
+		{
+			name: "negative session",
+			build: NewWeeklyScheduleBuilder().SetTemplate(TemplateWeekday, workday).SetTemplate(TemplateWeekend, workday).
+				SetSessions(-time.Hour, 0),
+			want: []string{"session lengths must not be negative"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.build.Build()
+			if err == nil {
+				t.Fatal("Build() succeeded")
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(err.Error(), w) {
+					t.Errorf("err = %v\nwant it to contain %q", err, w)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// benchLine matches one result of "go test -bench": name, iterations and
+// ns/op. The "-N" GOMAXPROCS suffix of the name is left out.
+var benchLine = regexp.MustCompile(`^(Benchmark\S+?)(?:-\d+)?\s+\d+\s+([0-9.]+) ns/op`)
+
+// runBenchTable implements "go run . benchtable [-bench prefix] [file...]":
+// it reads "go test -bench" output (stdin when no file is given) and
+// prints a Markdown table with one row per variant and one column per
+// configuration, as used in SINGLETON_PATTERN_GUIDE.md.
+func runBenchTable(args []string) error {
+	fs := flag.NewFlagSet("benchtable", flag.ContinueOnError)
+	prefix := fs.String("bench", "BenchmarkGetInstance", "benchmark de topo a tabular")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var in io.Reader = os.Stdin
+	if fs.NArg() > 0 {
+		var readers []io.Reader
+		for _, name := range fs.Args() {
+			f, err := os.Open(name)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			readers = append(readers, f)
+		}
+		in = io.MultiReader(readers...)
+	}
+	return writeBenchTable(os.Stdout, in, *prefix)
+}
+
+// writeBenchTable tabulates the sub-benchmarks of prefix named
+// prefix/<row>/<column...>. Repeated runs (-count) are reduced to their
+// median, and each cell also shows how many times slower it is than the
+// fastest variant in its column.
+func writeBenchTable(w io.Writer, r io.Reader, prefix string) error {
+	var rows, cols []string
+	results := make(map[[2]string][]float64)
+
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		m := benchLine.FindStringSubmatch(sc.Text())
+		if m == nil {
+			continue
+		}
+		name, ok := strings.CutPrefix(m[1], prefix+"/")
+		if !ok {
+			continue
+		}
+		row, col, _ := strings.Cut(name, "/")
+		if col == "" {
+			col = "ns/op"
+		}
+		nsPerOp, err := strconv.ParseFloat(m[2], 64)
+		if err != nil {
+			return fmt.Errorf("benchtable: %q: %w", sc.Text(), err)
+		}
+		if !slices.Contains(rows, row) {
+			rows = append(rows, row)
+		}
+		if !slices.Contains(cols, col) {
+			cols = append(cols, col)
+		}
+		cell := [2]string{row, col}
+		results[cell] = append(results[cell], nsPerOp)
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return fmt.Errorf("benchtable: nenhum resultado de %s/ na entrada", prefix)
+	}
+
+	medians := make(map[[2]string]float64, len(results))
+	fastest := make(map[string]float64, len(cols))
+	for cell, samples := range results {
+		m := median(samples)
+		medians[cell] = m
+		if f, ok := fastest[cell[1]]; !ok || m < f {
+			fastest[cell[1]] = m
+		}
+	}
+
+	fmt.Fprintf(w, "| Variante | %s |\n", strings.Join(cols, " | "))
+	fmt.Fprintf(w, "|---|%s\n", strings.Repeat("---:|", len(cols)))
+	for _, row := range rows {
+		cells := make([]string, len(cols))
+		for i, col := range cols {
+			m, ok := medians[[2]string{row, col}]
+			switch {
+			case !ok:
+				cells[i] = "-"
+			case fastest[col] > 0:
+				cells[i] = fmt.Sprintf("%.2f ns (%.1fx)", m, m/fastest[col])
+			default:
+				cells[i] = fmt.Sprintf("%.2f ns", m)
+			}
+		}
+		fmt.Fprintf(w, "| %s | %s |\n", row, strings.Join(cells, " | "))
+	}
+	return nil
+}
+
+func median(samples []float64) float64 {
+	s := slices.Clone(samples)
+	slices.Sort(s)
+	if n := len(s); n%2 == 0 {
+		return (s[n/2-1] + s[n/2]) / 2
+	}
+	return s[len(s)/2]
+}
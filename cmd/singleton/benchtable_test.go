@@ -0,0 +1,34 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteBenchTable(t *testing.T) {
+	input := `goos: linux
+BenchmarkGetInstance/example_2_mutex/procs=1-8         	1000	        30.00 ns/op
+BenchmarkGetInstance/example_2_mutex/procs=1-8         	1000	        20.00 ns/op
+BenchmarkGetInstance/example_2_mutex/procs=1-8         	1000	        25.00 ns/op
+BenchmarkGetInstance/example_3_once/procs=1-8          	1000	         2.50 ns/op
+BenchmarkGetInstance/example_3_once/procs=4-8          	1000	         3.00 ns/op
+BenchmarkStores/mutex/mixed-8                          	1000	        99.00 ns/op
+PASS
+`
+	var out strings.Builder
+	if err := writeBenchTable(&out, strings.NewReader(input), "BenchmarkGetInstance"); err != nil {
+		t.Fatal(err)
+	}
+	want := `| Variante | procs=1 | procs=4 |
+|---|---:|---:|
+| example_2_mutex | 25.00 ns (10.0x) | - |
+| example_3_once | 2.50 ns (1.0x) | 3.00 ns (1.0x) |
+`
+	if out.String() != want {
+		t.Fatalf("table =\n%s\nwant\n%s", out.String(), want)
+	}
+
+	if err := writeBenchTable(&out, strings.NewReader("PASS\n"), "BenchmarkGetInstance"); err == nil {
+		t.Fatal("expected an error for input without results")
+	}
+}
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Lifetime says how often a Container builds a service.
+type Lifetime int
+
+const (
+	// LifetimeSingleton builds the service once per container.
+	LifetimeSingleton Lifetime = iota
+	// LifetimeTransient builds a new service for every injection.
+	LifetimeTransient
+	// LifetimeScoped builds the service once per Scope.
+	LifetimeScoped
+)
+
+func (l Lifetime) String() string {
+	switch l {
+	case LifetimeSingleton:
+		return "singleton"
+	case LifetimeTransient:
+		return "transient"
+	case LifetimeScoped:
+		return "scoped"
+	}
+	return fmt.Sprintf("Lifetime(%d)", int(l))
+}
+
+var (
+	ErrNoProvider         = errors.New("container: no provider")
+	ErrProviderExists     = errors.New("container: provider already registered")
+	ErrInvalidConstructor = errors.New("container: invalid constructor")
+	ErrInjectionCycle     = errors.New("container: dependency cycle")
+	ErrScopeRequired      = errors.New("container: scoped service resolved outside a scope")
+	ErrCaptiveScope       = errors.New("container: singleton depends on a scoped service")
+)
+
+// Container is a dependency injection container: the explicit alternative
+// to package-level singletons. Services are registered by the type their
+// constructor returns, and the constructor's parameters are resolved from
+// the container by type.
+type Container struct {
+	mu        sync.RWMutex
+	providers map[reflect.Type]*provider
+	byName    map[string]*provider // Mesmo conteúdo, chaveado para dependencyOrder
+}
+
+type provider struct {
+	typ      reflect.Type
+	lifetime Lifetime
+	ctor     reflect.Value // Inválido nos bindings
+	params   []reflect.Type
+	bindTo   reflect.Type // Implementação, quando typ é uma interface ligada por Bind
+
+	mu    sync.Mutex // Serializa a construção do singleton
+	built bool
+	value reflect.Value
+}
+
+func NewContainer() *Container {
+	return &Container{
+		providers: make(map[reflect.Type]*provider),
+		byName:    make(map[string]*provider),
+	}
+}
+
+// Provide registers ctor as the way to build the type it returns. ctor
+// must be a function returning T or (T, error); its parameters are
+// injected from the container when T is resolved.
+func (c *Container) Provide(lifetime Lifetime, ctor any) error {
+	fn := reflect.ValueOf(ctor)
+	if fn.Kind() != reflect.Func {
+		return fmt.Errorf("%w: %T is not a function", ErrInvalidConstructor, ctor)
+	}
+	t := fn.Type()
+	if t.IsVariadic() || t.NumOut() == 0 || t.NumOut() > 2 ||
+		t.NumOut() == 2 && t.Out(1) != reflect.TypeFor[error]() {
+		return fmt.Errorf("%w: %T: want func(deps...) T or func(deps...) (T, error)", ErrInvalidConstructor, ctor)
+	}
+	p := &provider{typ: t.Out(0), lifetime: lifetime, ctor: fn}
+	for i := range t.NumIn() {
+		p.params = append(p.params, t.In(i))
+	}
+	return c.add(p)
+}
+
+// Bind makes the interface I resolve to the service registered for Impl,
+// which keeps its own lifetime: binding to a singleton hands out that
+// singleton.
+func Bind[I, Impl any](c *Container) error {
+	iface, impl := reflect.TypeFor[I](), reflect.TypeFor[Impl]()
+	if iface.Kind() != reflect.Interface {
+		return fmt.Errorf("container: bind %v: not an interface", iface)
+	}
+	if !impl.Implements(iface) {
+		return fmt.Errorf("container: bind %v: %v does not implement it", iface, impl)
+	}
+	return c.add(&provider{typ: iface, bindTo: impl})
+}
+
+func (c *Container) add(p *provider) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	name := p.typ.String()
+	if _, ok := c.providers[p.typ]; ok {
+		return fmt.Errorf("%w: %v", ErrProviderExists, p.typ)
+	}
+	if _, ok := c.byName[name]; ok {
+		// Tipos distintos com o mesmo nome tornariam as mensagens ambíguas
+		return fmt.Errorf("%w: another type is named %v", ErrProviderExists, p.typ)
+	}
+	c.providers[p.typ] = p
+	c.byName[name] = p
+	return nil
+}
+
+// Validate checks the whole graph for missing providers, cycles and
+// singletons that depend on a scoped service, so a wiring mistake fails
+// at startup rather than on first use.
+func (c *Container) Validate() error {
+	c.mu.RLock()
+	names := make([]string, 0, len(c.byName))
+	for name := range c.byName {
+		names = append(names, name)
+	}
+	c.mu.RUnlock()
+
+	slices.Sort(names)
+	order, err := c.order(names)
+	if err != nil {
+		return err
+	}
+	return c.checkCaptiveScopes(order)
+}
+
+// checkCaptiveScopes reports the first singleton that would need a scoped
+// service to be built. Scoped services reach it directly, through
+// transients or through bindings; another singleton in between is checked
+// on its own. order has every dependency before its dependents.
+func (c *Container) checkCaptiveScopes(order []*provider) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	// Caminho até um serviço com escopo, para quem o alcança sem passar
+	// por outro singleton
+	reaches := make(map[*provider][]string)
+	for _, p := range order {
+		if p.bindTo == nil && p.lifetime == LifetimeScoped {
+			reaches[p] = []string{p.typ.String()}
+			continue
+		}
+		for _, name := range p.deps() {
+			dep := c.byName[name]
+			path := reaches[dep]
+			if path == nil || dep.bindTo == nil && dep.lifetime == LifetimeSingleton {
+				continue
+			}
+			if p.bindTo == nil && p.lifetime == LifetimeSingleton {
+				return fmt.Errorf("%w: %v -> %s", ErrCaptiveScope, p.typ, strings.Join(path, " -> "))
+			}
+			reaches[p] = append([]string{p.typ.String()}, path...)
+			break
+		}
+	}
+	return nil
+}
+
+func (c *Container) order(roots []string) ([]*provider, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return dependencyOrder(roots, func(name string) (*provider, []string, bool) {
+		p, ok := c.byName[name]
+		if !ok {
+			return nil, nil, false
+		}
+		return p, p.deps(), true
+	}, ErrNoProvider, ErrInjectionCycle)
+}
+
+func (p *provider) deps() []string {
+	if p.bindTo != nil {
+		return []string{p.bindTo.String()}
+	}
+	deps := make([]string, len(p.params))
+	for i, t := range p.params {
+		deps[i] = t.String()
+	}
+	return deps
+}
+
+// Scope caches the scoped services of one unit of work, typically a
+// request. Singletons still come from the parent container.
+type Scope struct {
+	c *Container
+
+	mu     sync.Mutex
+	values map[*provider]*scopedValue
+}
+
+type scopedValue struct {
+	mu    sync.Mutex
+	built bool
+	value reflect.Value
+}
+
+func (c *Container) NewScope() *Scope {
+	return &Scope{c: c, values: make(map[*provider]*scopedValue)}
+}
+
+// Resolver is a Container or a Scope.
+type Resolver interface {
+	resolveType(t reflect.Type) (reflect.Value, error)
+}
+
+// Resolve returns the service registered for T, building it and its
+// dependencies as their lifetimes require. Scoped services can only be
+// resolved from a Scope, and never become dependencies of a singleton.
+func Resolve[T any](r Resolver) (T, error) {
+	var zero T
+	v, err := r.resolveType(reflect.TypeFor[T]())
+	if err != nil {
+		return zero, err
+	}
+	t, _ := v.Interface().(T) // nil de interface não passa no type assertion
+	return t, nil
+}
+
+func (c *Container) resolveType(t reflect.Type) (reflect.Value, error) {
+	return c.resolveIn(t, nil)
+}
+
+func (s *Scope) resolveType(t reflect.Type) (reflect.Value, error) {
+	return s.c.resolveIn(t, s)
+}
+
+func (c *Container) resolveIn(t reflect.Type, scope *Scope) (reflect.Value, error) {
+	order, err := c.order([]string{t.String()})
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if err := c.checkCaptiveScopes(order); err != nil {
+		return reflect.Value{}, err
+	}
+	return c.resolveProvider(t, scope)
+}
+
+func (c *Container) resolveProvider(t reflect.Type, scope *Scope) (reflect.Value, error) {
+	c.mu.RLock()
+	p := c.providers[t]
+	c.mu.RUnlock()
+	if p == nil {
+		// Mesmo nome, outro tipo: dependencyOrder não distingue os dois
+		return reflect.Value{}, fmt.Errorf("%w: %v", ErrNoProvider, t)
+	}
+	return c.resolve(p, scope)
+}
+
+// resolve builds p. The graph below p was already checked by resolveIn,
+// so the recursion terminates and the provider locks, always taken from
+// dependent to dependency, cannot deadlock.
+func (c *Container) resolve(p *provider, scope *Scope) (reflect.Value, error) {
+	switch {
+	case p.bindTo != nil:
+		v, err := c.resolveProvider(p.bindTo, scope)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		out := reflect.New(p.typ).Elem()
+		out.Set(v)
+		return out, nil
+
+	case p.lifetime == LifetimeSingleton:
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.built {
+			return p.value, nil
+		}
+		// Um singleton vive mais que qualquer escopo: constrói sem ele
+		v, err := c.build(p, nil)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		p.value, p.built = v, true
+		return v, nil
+
+	case p.lifetime == LifetimeScoped:
+		if scope == nil {
+			return reflect.Value{}, fmt.Errorf("%w: %v", ErrScopeRequired, p.typ)
+		}
+		scope.mu.Lock()
+		sv, ok := scope.values[p]
+		if !ok {
+			sv = &scopedValue{}
+			scope.values[p] = sv
+		}
+		scope.mu.Unlock()
+
+		sv.mu.Lock()
+		defer sv.mu.Unlock()
+		if sv.built {
+			return sv.value, nil
+		}
+		v, err := c.build(p, scope)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		sv.value, sv.built = v, true
+		return v, nil
+	}
+	return c.build(p, scope)
+}
+
+func (c *Container) build(p *provider, scope *Scope) (reflect.Value, error) {
+	args := make([]reflect.Value, len(p.params))
+	for i, t := range p.params {
+		v, err := c.resolveProvider(t, scope)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("%w (required by %v %v)", err, p.lifetime, p.typ)
+		}
+		args[i] = v
+	}
+	out := p.ctor.Call(args)
+	if len(out) == 2 && !out[1].IsNil() {
+		return reflect.Value{}, fmt.Errorf("container: building %v: %w", p.typ, out[1].Interface().(error))
+	}
+	return out[0], nil
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// Com um campo cada: ponteiros para structs vazias podem ser iguais
+type (
+	testConfig  struct{ _ int }
+	testRequest struct{ _ int }
+	testRepo    struct{ _ int }
+	testService struct{ _ int }
+	testReader  interface{ Read() string }
+)
+
+func (*testRequest) Read() string { return "request" }
+
+func TestContainerValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		provide func(c *Container) error
+		err     error
+		mention string
+	}{
+		{
+			name: "valid",
+			provide: func(c *Container) error {
+				return errors.Join(
+					c.Provide(LifetimeSingleton, func() *testConfig { return &testConfig{} }),
+					c.Provide(LifetimeScoped, func(*testConfig) *testRequest { return &testRequest{} }),
+					c.Provide(LifetimeTransient, func(*testRequest, *testConfig) *testService { return &testService{} }),
+				)
+			},
+		},
+		{
+			name: "missing provider",
+			provide: func(c *Container) error {
+				return c.Provide(LifetimeSingleton, func(*testConfig) *testService { return &testService{} })
+			},
+			err: ErrNoProvider,
+		},
+		{
+			name: "cycle",
+			provide: func(c *Container) error {
+				return errors.Join(
+					c.Provide(LifetimeSingleton, func(*testService) *testRepo { return &testRepo{} }),
+					c.Provide(LifetimeTransient, func(*testRepo) *testService { return &testService{} }),
+				)
+			},
+			err: ErrInjectionCycle,
+		},
+		{
+			name: "singleton on scoped",
+			provide: func(c *Container) error {
+				return errors.Join(
+					c.Provide(LifetimeScoped, func() *testRequest { return &testRequest{} }),
+					c.Provide(LifetimeSingleton, func(*testRequest) *testService { return &testService{} }),
+				)
+			},
+			err:     ErrCaptiveScope,
+			mention: "*main.testService -> *main.testRequest",
+		},
+		{
+			name: "singleton on scoped through a transient",
+			provide: func(c *Container) error {
+				return errors.Join(
+					c.Provide(LifetimeScoped, func() *testRequest { return &testRequest{} }),
+					c.Provide(LifetimeTransient, func(*testRequest) *testRepo { return &testRepo{} }),
+					c.Provide(LifetimeSingleton, func(*testRepo) *testService { return &testService{} }),
+				)
+			},
+			err:     ErrCaptiveScope,
+			mention: "*main.testService -> *main.testRepo -> *main.testRequest",
+		},
+		{
+			name: "singleton on scoped through a binding",
+			provide: func(c *Container) error {
+				return errors.Join(
+					c.Provide(LifetimeScoped, func() *testRequest { return &testRequest{} }),
+					Bind[testReader, *testRequest](c),
+					c.Provide(LifetimeSingleton, func(testReader) *testService { return &testService{} }),
+				)
+			},
+			err:     ErrCaptiveScope,
+			mention: "*main.testService -> main.testReader -> *main.testRequest",
+		},
+		{
+			name: "scoped behind another singleton",
+			provide: func(c *Container) error {
+				return errors.Join(
+					c.Provide(LifetimeScoped, func() *testRequest { return &testRequest{} }),
+					c.Provide(LifetimeSingleton, func(*testRequest) *testRepo { return &testRepo{} }),
+					c.Provide(LifetimeSingleton, func(*testRepo) *testService { return &testService{} }),
+				)
+			},
+			err:     ErrCaptiveScope,
+			mention: "*main.testRepo -> *main.testRequest",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewContainer()
+			if err := tt.provide(c); err != nil {
+				t.Fatal(err)
+			}
+			err := c.Validate()
+			if tt.err == nil {
+				if err != nil {
+					t.Fatalf("Validate = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.err) || !strings.Contains(err.Error(), tt.mention) {
+				t.Fatalf("Validate = %v, want %v mentioning %q", err, tt.err, tt.mention)
+			}
+			// Resolver no escopo falha do mesmo jeito, antes de construir
+			if _, rerr := Resolve[*testService](c.NewScope()); !errors.Is(rerr, tt.err) {
+				t.Errorf("Resolve = %v, want %v", rerr, tt.err)
+			}
+		})
+	}
+}
+
+func TestContainerLifetimes(t *testing.T) {
+	c := NewContainer()
+	built := 0
+	if err := errors.Join(
+		c.Provide(LifetimeSingleton, func() *testConfig { built++; return &testConfig{} }),
+		c.Provide(LifetimeScoped, func(*testConfig) *testRequest { return &testRequest{} }),
+		c.Provide(LifetimeTransient, func(*testRequest) *testService { return &testService{} }),
+		Bind[testReader, *testRequest](c),
+		c.Validate(),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	a, b := c.NewScope(), c.NewScope()
+	reqA1, _ := Resolve[*testRequest](a)
+	reqA2, _ := Resolve[*testRequest](a)
+	reqB, _ := Resolve[*testRequest](b)
+	if reqA1 != reqA2 || reqA1 == reqB {
+		t.Error("scoped service not cached once per scope")
+	}
+	if r, _ := Resolve[testReader](a); r != testReader(reqA1) {
+		t.Error("binding did not hand out the scoped implementation")
+	}
+	if s1, _ := Resolve[*testService](a); s1 == nil {
+		t.Error("transient service not built")
+	}
+	if built != 1 {
+		t.Errorf("singleton built %d times", built)
+	}
+	if _, err := Resolve[*testRequest](c); !errors.Is(err, ErrScopeRequired) {
+		t.Errorf("Resolve outside a scope = %v, want ErrScopeRequired", err)
+	}
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"container/list"
+	"sync"
+)
+
+// EvictionPolicy decides which key leaves the store once it is over
+// capacity. The store reports every insert, read and removal; Victim is
+// only asked for a key when something has to go.
+//
+// Implementations must be safe for concurrent use: Accessed is called
+// from Get while the store only holds its read lock.
+type EvictionPolicy[K comparable] interface {
+	Added(key K)
+	Accessed(key K)
+	Removed(key K)
+	Victim() (K, bool)
+}
+
+// victimSkipper is implemented by the built-in policies. victimExcept is
+// Victim passing over key, so an overwrite can evict around the key being
+// written without the policy forgetting where that key stands.
+type victimSkipper[K comparable] interface {
+	victimExcept(key K) (K, bool)
+}
+
+// policyCloner is implemented by the built-in policies: fresh returns a
+// new, empty policy of the same kind, so a sharded store can give each
+// shard its own instance.
+type policyCloner[K comparable] interface {
+	fresh() EvictionPolicy[K]
+}
+
+// lruPolicy evicts the least recently used key.
+type lruPolicy[K comparable] struct {
+	mu    sync.Mutex
+	order *list.List // Frente = mais recente
+	items map[K]*list.Element
+}
+
+func NewLRUPolicy[K comparable]() EvictionPolicy[K] {
+	return &lruPolicy[K]{order: list.New(), items: make(map[K]*list.Element)}
+}
+
+func (p *lruPolicy[K]) fresh() EvictionPolicy[K] { return NewLRUPolicy[K]() }
+
+func (p *lruPolicy[K]) Added(key K) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if el, ok := p.items[key]; ok {
+		p.order.MoveToFront(el)
+		return
+	}
+	p.items[key] = p.order.PushFront(key)
+}
+
+func (p *lruPolicy[K]) Accessed(key K) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if el, ok := p.items[key]; ok {
+		p.order.MoveToFront(el)
+	}
+}
+
+func (p *lruPolicy[K]) Removed(key K) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if el, ok := p.items[key]; ok {
+		p.order.Remove(el)
+		delete(p.items, key)
+	}
+}
+
+func (p *lruPolicy[K]) Victim() (K, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	el := p.order.Back()
+	if el == nil {
+		var zero K
+		return zero, false
+	}
+	return el.Value.(K), true
+}
+
+func (p *lruPolicy[K]) victimExcept(key K) (K, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for el := p.order.Back(); el != nil; el = el.Prev() {
+		if k := el.Value.(K); k != key {
+			return k, true
+		}
+	}
+	var zero K
+	return zero, false
+}
+
+// fifoPolicy evicts the oldest inserted key; reads and overwrites do not
+// change its position.
+type fifoPolicy[K comparable] struct {
+	mu    sync.Mutex
+	order *list.List // Frente = mais antigo
+	items map[K]*list.Element
+}
+
+func NewFIFOPolicy[K comparable]() EvictionPolicy[K] {
+	return &fifoPolicy[K]{order: list.New(), items: make(map[K]*list.Element)}
+}
+
+func (p *fifoPolicy[K]) fresh() EvictionPolicy[K] { return NewFIFOPolicy[K]() }
+
+func (p *fifoPolicy[K]) Added(key K) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.items[key]; !ok {
+		p.items[key] = p.order.PushBack(key)
+	}
+}
+
+func (p *fifoPolicy[K]) Accessed(key K) {}
+
+func (p *fifoPolicy[K]) Removed(key K) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if el, ok := p.items[key]; ok {
+		p.order.Remove(el)
+		delete(p.items, key)
+	}
+}
+
+func (p *fifoPolicy[K]) Victim() (K, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	el := p.order.Front()
+	if el == nil {
+		var zero K
+		return zero, false
+	}
+	return el.Value.(K), true
+}
+
+func (p *fifoPolicy[K]) victimExcept(key K) (K, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for el := p.order.Front(); el != nil; el = el.Next() {
+		if k := el.Value.(K); k != key {
+			return k, true
+		}
+	}
+	var zero K
+	return zero, false
+}
+
+// lfuPolicy evicts the least frequently used key. Keys are kept in one
+// list per frequency so every operation is O(1); ties are broken by
+// evicting the least recently used key of the lowest frequency.
+type lfuPolicy[K comparable] struct {
+	mu      sync.Mutex
+	buckets map[int]*list.List // frequência -> chaves (frente = mais recente)
+	items   map[K]*lfuItem
+	minFreq int
+}
+
+type lfuItem struct {
+	freq int
+	el   *list.Element
+}
+
+func NewLFUPolicy[K comparable]() EvictionPolicy[K] {
+	return &lfuPolicy[K]{buckets: make(map[int]*list.List), items: make(map[K]*lfuItem)}
+}
+
+func (p *lfuPolicy[K]) fresh() EvictionPolicy[K] { return NewLFUPolicy[K]() }
+
+func (p *lfuPolicy[K]) Added(key K) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.items[key]; ok {
+		p.touch(key)
+		return
+	}
+	p.items[key] = &lfuItem{freq: 1, el: p.bucket(1).PushFront(key)}
+	p.minFreq = 1
+}
+
+func (p *lfuPolicy[K]) Accessed(key K) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.items[key]; ok {
+		p.touch(key)
+	}
+}
+
+func (p *lfuPolicy[K]) Removed(key K) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	item, ok := p.items[key]
+	if !ok {
+		return
+	}
+	p.unlink(item)
+	delete(p.items, key)
+}
+
+func (p *lfuPolicy[K]) Victim() (K, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.items) == 0 {
+		var zero K
+		return zero, false
+	}
+	// minFreq pode estar desatualizado depois de remoções
+	for p.buckets[p.minFreq] == nil {
+		p.minFreq++
+	}
+	return p.buckets[p.minFreq].Back().Value.(K), true
+}
+
+func (p *lfuPolicy[K]) victimExcept(key K) (K, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var zero K
+	skip, has := p.items[key]
+	if len(p.items) == 0 || (has && len(p.items) == 1) {
+		return zero, false
+	}
+	for p.buckets[p.minFreq] == nil {
+		p.minFreq++
+	}
+	el := p.buckets[p.minFreq].Back()
+	if !has || el != skip.el {
+		return el.Value.(K), true
+	}
+	if prev := el.Prev(); prev != nil {
+		return prev.Value.(K), true
+	}
+	// key é a única chave da menor frequência: a vítima vem da seguinte
+	next := 0
+	for freq := range p.buckets {
+		if freq > p.minFreq && (next == 0 || freq < next) {
+			next = freq
+		}
+	}
+	return p.buckets[next].Back().Value.(K), true
+}
+
+// touch moves key to the next frequency bucket. Caller holds p.mu.
+func (p *lfuPolicy[K]) touch(key K) {
+	item := p.items[key]
+	p.unlink(item)
+	if item.freq == p.minFreq && p.buckets[item.freq] == nil {
+		p.minFreq++
+	}
+	item.freq++
+	item.el = p.bucket(item.freq).PushFront(key)
+}
+
+func (p *lfuPolicy[K]) unlink(item *lfuItem) {
+	b := p.buckets[item.freq]
+	b.Remove(item.el)
+	if b.Len() == 0 {
+		delete(p.buckets, item.freq)
+	}
+}
+
+func (p *lfuPolicy[K]) bucket(freq int) *list.List {
+	b, ok := p.buckets[freq]
+	if !ok {
+		b = list.New()
+		p.buckets[freq] = b
+	}
+	return b
+}
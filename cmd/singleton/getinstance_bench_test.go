@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+)
+
+// BenchmarkGetInstance measures the steady-state cost of each
+// GetInstance_example_N after the instance exists, at several GOMAXPROCS
+// values and contention levels. "goroutines=Nx" runs N goroutines per
+// GOMAXPROCS, all calling GetInstance in a tight loop. Turn the output
+// into the guide's table with:
+//
+//	go test -run '^$' -bench GetInstance -count 5 ./cmd/singleton | go run ./cmd/singleton benchtable
+func BenchmarkGetInstance(b *testing.B) {
+	for _, v := range getInstanceVariants {
+		b.Run(v.name, func(b *testing.B) {
+			withFreshInstance(b)
+			v.get() // A primeira chamada não é medida, e o exemplo 1 só é seguro depois dela
+
+			for _, procs := range []int{1, 2, 4, 8} {
+				for _, perProc := range []int{1, 16} {
+					b.Run(fmt.Sprintf("procs=%d/goroutines=%dx", procs, perProc), func(b *testing.B) {
+						defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(procs))
+						b.SetParallelism(perProc)
+						b.RunParallel(func(pb *testing.PB) {
+							for pb.Next() {
+								if v.get() == nil {
+									b.Error("GetInstance returned nil")
+									return
+								}
+							}
+						})
+					})
+				}
+			}
+		})
+	}
+}
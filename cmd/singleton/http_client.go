@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// HTTPClient talks to a store served by "go run . serve". It has the same
+// method set as the in-process singleton, so code written against
+// kvStore works with either.
+//
+// The kvStore methods cannot return errors; transport and server errors
+// are passed to OnError (log.Printf by default) and reads report a miss.
+// A nil Client uses http.DefaultClient.
+type HTTPClient struct {
+	BaseURL string
+	Client  *http.Client
+	OnError func(error)
+}
+
+var _ kvStore = (*HTTPClient)(nil)
+
+func NewHTTPClient(baseURL string) *HTTPClient {
+	return &HTTPClient{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		Client:  &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (c *HTTPClient) Set(key, value string) {
+	c.SetWithTTL(key, value, 0)
+}
+
+func (c *HTTPClient) SetWithTTL(key, value string, ttl time.Duration) {
+	u := c.keyURL(key)
+	if ttl > 0 {
+		u += "?ttl=" + url.QueryEscape(ttl.String())
+	}
+	c.do(http.MethodPut, u, strings.NewReader(value), nil)
+}
+
+func (c *HTTPClient) Get(key string) (string, bool) {
+	var value string
+	status := c.do(http.MethodGet, c.keyURL(key), nil, func(body io.Reader) error {
+		b, err := io.ReadAll(body)
+		value = string(b)
+		return err
+	})
+	return value, status == http.StatusOK
+}
+
+func (c *HTTPClient) Delete(key string) {
+	c.do(http.MethodDelete, c.keyURL(key), nil, nil)
+}
+
+// CompareAndSwap stores new only if key currently holds old, sending the
+// tag of old in If-Match. Unlike the in-process version, the key takes
+// no TTL after the swap.
+func (c *HTTPClient) CompareAndSwap(key, old, new string) bool {
+	req, err := http.NewRequest(http.MethodPut, c.keyURL(key), strings.NewReader(new))
+	if err != nil {
+		c.fail(err)
+		return false
+	}
+	req.Header.Set("If-Match", valueETag(old))
+	return c.send(req, nil) == http.StatusNoContent
+}
+
+func (c *HTTPClient) Size() int {
+	return c.Stats().Size
+}
+
+// Stats fetches the counters of the remote store.
+func (c *HTTPClient) Stats() Stats {
+	var stats Stats
+	c.do(http.MethodGet, c.BaseURL+"/stats", nil, func(body io.Reader) error {
+		return json.NewDecoder(body).Decode(&stats)
+	})
+	return stats
+}
+
+// List returns the entries whose key starts with prefix, sorted by key.
+func (c *HTTPClient) List(prefix string) []kvEntry {
+	var list []kvEntry
+	c.do(http.MethodGet, c.BaseURL+"/kv?prefix="+url.QueryEscape(prefix), nil, func(body io.Reader) error {
+		return json.NewDecoder(body).Decode(&list)
+	})
+	return list
+}
+
+func (c *HTTPClient) keyURL(key string) string {
+	return c.BaseURL + "/kv/" + url.PathEscape(key)
+}
+
+// do sends a request and hands a 200 body to decode. It returns the
+// status code, or 0 when the request failed.
+func (c *HTTPClient) do(method, u string, body io.Reader, decode func(io.Reader) error) int {
+	req, err := http.NewRequest(method, u, body)
+	if err != nil {
+		c.fail(err)
+		return 0
+	}
+	return c.send(req, decode)
+}
+
+func (c *HTTPClient) send(req *http.Request, decode func(io.Reader) error) int {
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		c.fail(err)
+		return 0
+	}
+	defer resp.Body.Close()
+
+	switch code := resp.StatusCode; {
+	case code == http.StatusOK && decode != nil:
+		if err := decode(resp.Body); err != nil {
+			c.fail(fmt.Errorf("%s %s: decode: %w", req.Method, req.URL, err))
+			return 0
+		}
+	// 404 e 412 são respostas esperadas de Get e CompareAndSwap
+	case code >= 300 && code != http.StatusNotFound && code != http.StatusPreconditionFailed:
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		c.fail(fmt.Errorf("%s %s: %s: %s", req.Method, req.URL, resp.Status, strings.TrimSpace(string(msg))))
+	}
+	return resp.StatusCode
+}
+
+func (c *HTTPClient) fail(err error) {
+	if c.OnError != nil {
+		c.OnError(err)
+		return
+	}
+	log.Printf("singleton http client: %v", err)
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"time"
+)
+
+// maxValueBytes limits the body of PUT /kv/{key}.
+const maxValueBytes = 1 << 20
+
+// kvEntry is the JSON form of one entry in listings.
+type kvEntry struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// newHTTPHandler exposes s over HTTP:
+//
+//	GET    /kv/{key}          valor em text/plain, 404 se ausente; ETag e,
+//	                          com TTL, Expires
+//	PUT    /kv/{key}[?ttl=1m] corpo da requisição vira o valor; com
+//	                          If-Match, só se o ETag bater (senão 412)
+//	DELETE /kv/{key}
+//	GET    /kv?prefix=p       lista ordenada de {key, value}
+//	GET    /stats             contadores em JSON
+//	GET    /metrics           formato de exposição do Prometheus
+func newHTTPHandler(s *singleton) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /kv/{key}", func(w http.ResponseWriter, r *http.Request) {
+		key := r.PathValue("key")
+		value, ok := s.Get(key)
+		if !ok {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("ETag", valueETag(value))
+		if ttl, ok := s.TTL(key); ok && ttl > 0 {
+			w.Header().Set("Expires", time.Now().Add(ttl).UTC().Format(http.TimeFormat))
+		}
+		io.WriteString(w, value)
+	})
+
+	mux.HandleFunc("PUT /kv/{key}", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxValueBytes))
+		if err != nil {
+			status := http.StatusBadRequest
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				status = http.StatusRequestEntityTooLarge
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+		var ttl time.Duration
+		if raw := r.URL.Query().Get("ttl"); raw != "" {
+			if ttl, err = time.ParseDuration(raw); err != nil {
+				http.Error(w, "invalid ttl: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		key := r.PathValue("key")
+		match := r.Header.Get("If-Match")
+		if match == "" {
+			s.SetWithTTL(key, string(body), ttl)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		var swapped bool
+		s.Txn(func(tx *Tx) error {
+			current, ok := tx.Get(key)
+			if swapped = ok && (match == "*" || match == valueETag(current)); swapped {
+				tx.SetWithTTL(key, string(body), ttl)
+			}
+			return nil
+		})
+		if !swapped {
+			http.Error(w, "value changed", http.StatusPreconditionFailed)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	mux.HandleFunc("DELETE /kv/{key}", func(w http.ResponseWriter, r *http.Request) {
+		s.Delete(r.PathValue("key"))
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	mux.HandleFunc("GET /kv", func(w http.ResponseWriter, r *http.Request) {
+		list := s.sortedEntries(r.URL.Query().Get("prefix"))
+		if list == nil {
+			list = []kvEntry{}
+		}
+		writeJSON(w, list)
+	})
+
+	mux.HandleFunc("GET /stats", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, s.Stats())
+	})
+
+	mux.Handle("GET /metrics", newMetricsHandler("singleton", s.Stats))
+
+	return mux
+}
+
+// valueETag is the strong entity tag of a value: equal values have equal
+// tags, so a client can compute the tag of a value it read earlier.
+func valueETag(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("http: encode response: %v", err)
+	}
+}
+
+// httpComponent runs an http.Server as a Lifecycle component.
+type httpComponent struct {
+	srv *http.Server
+}
+
+func (h *httpComponent) Start(ctx context.Context) error {
+	l, err := net.Listen("tcp", h.srv.Addr)
+	if err != nil {
+		return err
+	}
+	log.Printf("singleton HTTP em http://%s", l.Addr())
+	go func() {
+		if err := h.srv.Serve(l); !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("http: %v", err)
+		}
+	}()
+	return nil
+}
+
+func (h *httpComponent) Stop(ctx context.Context) error {
+	return h.srv.Shutdown(ctx)
+}
+
+// runHTTPServer implements "go run . serve": it serves the process
+// singleton until SIGINT/SIGTERM and then shuts down gracefully.
+func runHTTPServer(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := fs.String("addr", "localhost:8080", "endereço de escuta")
+	latency := fs.Bool("latency", false, "mede a latência das operações em /metrics")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	store := GetInstance_example_3()
+	store.SetLatencyTracking(*latency)
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           newHTTPHandler(store),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	m := NewLifecycleManager()
+	m.Add("store", store)
+	m.Add("http", &httpComponent{srv: srv}, "store")
+	return m.Run(context.Background(), 5*time.Second)
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+// httpDo sends one request to h and returns the recorded response.
+func httpDo(h http.Handler, method, target string, body io.Reader, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, body)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHTTPStatusCodes(t *testing.T) {
+	h := newHTTPHandler(newSingleton())
+	tests := []struct {
+		name, method, target string
+		body                 io.Reader
+		want                 int
+	}{
+		{"get missing", "GET", "/kv/a", nil, http.StatusNotFound},
+		{"put", "PUT", "/kv/a", strings.NewReader("1"), http.StatusNoContent},
+		{"get", "GET", "/kv/a", nil, http.StatusOK},
+		{"put with ttl", "PUT", "/kv/b?ttl=1m", strings.NewReader("2"), http.StatusNoContent},
+		{"invalid ttl", "PUT", "/kv/b?ttl=soon", strings.NewReader("2"), http.StatusBadRequest},
+		{"too large", "PUT", "/kv/c", strings.NewReader(strings.Repeat("x", maxValueBytes+1)), http.StatusRequestEntityTooLarge},
+		{"body read error", "PUT", "/kv/c", iotest.ErrReader(errors.New("conexão caiu")), http.StatusBadRequest},
+		{"list", "GET", "/kv?prefix=a", nil, http.StatusOK},
+		{"stats", "GET", "/stats", nil, http.StatusOK},
+		{"metrics", "GET", "/metrics", nil, http.StatusOK},
+		{"delete", "DELETE", "/kv/a", nil, http.StatusNoContent},
+		{"get deleted", "GET", "/kv/a", nil, http.StatusNotFound},
+		{"wrong method", "POST", "/kv/a", nil, http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		if rec := httpDo(h, tt.method, tt.target, tt.body); rec.Code != tt.want {
+			t.Errorf("%s: %s %s = %d, want %d (%s)", tt.name, tt.method, tt.target, rec.Code, tt.want, rec.Body)
+		}
+	}
+	if rec := httpDo(h, "GET", "/kv/b", nil); rec.Body.String() != "2" {
+		t.Errorf("GET /kv/b = %q, want 2", rec.Body)
+	}
+	if rec := httpDo(h, "GET", "/kv?prefix=", nil); strings.TrimSpace(rec.Body.String()) != `[{"key":"b","value":"2"}]` {
+		t.Errorf("GET /kv = %s", rec.Body)
+	}
+}
+
+func TestHTTPTTLHeaders(t *testing.T) {
+	h := newHTTPHandler(newSingleton())
+	httpDo(h, "PUT", "/kv/sessao?ttl=1h", strings.NewReader("x"))
+	httpDo(h, "PUT", "/kv/fixa", strings.NewReader("y"))
+
+	rec := httpDo(h, "GET", "/kv/sessao", nil)
+	expires, err := http.ParseTime(rec.Header().Get("Expires"))
+	if err != nil {
+		t.Fatalf("Expires = %q: %v", rec.Header().Get("Expires"), err)
+	}
+	if left := time.Until(expires); left < 59*time.Minute || left > time.Hour+time.Second {
+		t.Errorf("Expires in %v, want about 1h", left)
+	}
+	if rec := httpDo(h, "GET", "/kv/fixa", nil); rec.Header().Get("Expires") != "" {
+		t.Errorf("key without TTL has Expires %q", rec.Header().Get("Expires"))
+	}
+
+	// Um PUT sem ttl tira o TTL, como SetWithTTL com zero
+	httpDo(h, "PUT", "/kv/sessao", strings.NewReader("x"))
+	if rec := httpDo(h, "GET", "/kv/sessao", nil); rec.Header().Get("Expires") != "" {
+		t.Errorf("Expires %q after a PUT without ttl", rec.Header().Get("Expires"))
+	}
+}
+
+func TestHTTPCompareAndSwap(t *testing.T) {
+	h := newHTTPHandler(newSingleton())
+	httpDo(h, "PUT", "/kv/k", strings.NewReader("v1"))
+	tag := httpDo(h, "GET", "/kv/k", nil).Header().Get("ETag")
+	if tag != valueETag("v1") {
+		t.Fatalf("ETag = %q, want %q", tag, valueETag("v1"))
+	}
+
+	tests := []struct {
+		name, target, match, body string
+		want                      int
+		value                     string // Valor de k depois
+	}{
+		{"stale tag", "/kv/k", valueETag("v0"), "x", http.StatusPreconditionFailed, "v1"},
+		{"current tag", "/kv/k", tag, "v2", http.StatusNoContent, "v2"},
+		{"tag already used", "/kv/k", tag, "v3", http.StatusPreconditionFailed, "v2"},
+		{"any value", "/kv/k", "*", "v4", http.StatusNoContent, "v4"},
+		{"missing key", "/kv/nada", "*", "x", http.StatusPreconditionFailed, "v4"},
+	}
+	for _, tt := range tests {
+		rec := httpDo(h, "PUT", tt.target, strings.NewReader(tt.body), "If-Match", tt.match)
+		if rec.Code != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.name, rec.Code, tt.want)
+		}
+		if got := httpDo(h, "GET", "/kv/k", nil).Body.String(); got != tt.value {
+			t.Errorf("%s: k = %q, want %q", tt.name, got, tt.value)
+		}
+	}
+	if rec := httpDo(h, "GET", "/kv/nada", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("failed If-Match created the key: %d", rec.Code)
+	}
+}
+
+func TestHTTPClient(t *testing.T) {
+	srv := httptest.NewServer(newHTTPHandler(newSingleton()))
+	defer srv.Close()
+
+	var errs []error
+	// Valor zero, sem Client: usa http.DefaultClient
+	c := &HTTPClient{BaseURL: srv.URL, OnError: func(err error) { errs = append(errs, err) }}
+	c.Set("a/b", "1")
+	if v, ok := c.Get("a/b"); !ok || v != "1" {
+		t.Errorf("Get = %q, %t", v, ok)
+	}
+	if _, ok := c.Get("nada"); ok {
+		t.Error("Get of a missing key reported a hit")
+	}
+	if c.CompareAndSwap("a/b", "0", "2") {
+		t.Error("CompareAndSwap with a stale value swapped")
+	}
+	if !c.CompareAndSwap("a/b", "1", "2") {
+		t.Error("CompareAndSwap with the current value failed")
+	}
+	if v, _ := c.Get("a/b"); v != "2" || c.Size() != 1 {
+		t.Errorf("a/b = %q, size %d", v, c.Size())
+	}
+	c.Delete("a/b")
+	if len(c.List("")) != 0 {
+		t.Errorf("List after Delete = %v", c.List(""))
+	}
+	if len(errs) != 0 {
+		t.Errorf("OnError got %v", errs)
+	}
+
+	c.Set("x", strings.Repeat("x", maxValueBytes+1))
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "413") {
+		t.Errorf("errors = %v, want one 413", errs)
+	}
+}
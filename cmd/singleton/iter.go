@@ -0,0 +1,115 @@
+package main
+
+import (
+	"container/heap"
+	"iter"
+	"slices"
+	"strings"
+)
+
+// The iteration methods below work on a point-in-time copy of the store:
+// the read lock is held only while the live entries are copied, so
+// writers are never blocked by a slow consumer, and changes made during
+// the iteration (including by the consumer itself) are not observed.
+// Keys are always visited in ascending byte order.
+
+// sortedEntries copies the live entries whose key starts with prefix and
+// sorts them by key.
+func (s *singleton) sortedEntries(prefix string) []kvEntry {
+	var list []kvEntry
+	for _, e := range s.entriesWhere(func(key string) bool { return strings.HasPrefix(key, prefix) }) {
+		list = append(list, kvEntry{Key: e.Key, Value: e.Value})
+	}
+	slices.SortFunc(list, compareKeys)
+	return list
+}
+
+func compareKeys(a, b kvEntry) int { return strings.Compare(a.Key, b.Key) }
+
+// Range calls fn for every entry in key order until fn returns false.
+func (s *singleton) Range(fn func(key, value string) bool) {
+	for _, e := range s.sortedEntries("") {
+		if !fn(e.Key, e.Value) {
+			return
+		}
+	}
+}
+
+// All returns an iterator over every entry in key order, for use with
+// range-over-func:
+//
+//	for k, v := range s.All() { ... }
+func (s *singleton) All() iter.Seq2[string, string] {
+	return s.Range
+}
+
+// Keys returns the keys starting with prefix, sorted. An empty prefix
+// returns every key.
+func (s *singleton) Keys(prefix string) []string {
+	list := s.sortedEntries(prefix)
+	keys := make([]string, len(list))
+	for i, e := range list {
+		keys[i] = e.Key
+	}
+	return keys
+}
+
+// Scan returns up to limit entries with start <= key < end in key order.
+// An empty end means no upper bound and limit <= 0 means no limit.
+//
+// When more entries remain, next is the key to pass as start to fetch the
+// following page; it is "" once the range is exhausted. Each page is read
+// from its own snapshot, so keys written between calls may or may not
+// appear, but no key present throughout the scan is skipped or repeated.
+//
+// Only the keys in range are copied, and only the limit+1 smallest of
+// them are sorted, so paging through the store does not sort it again
+// on every call.
+func (s *singleton) Scan(start, end string, limit int) (page []kvEntry, next string) {
+	inRange := s.entriesWhere(func(key string) bool {
+		return key >= start && (end == "" || key < end)
+	})
+	list := make([]kvEntry, len(inRange))
+	for i, e := range inRange {
+		list[i] = kvEntry{Key: e.Key, Value: e.Value}
+	}
+	if limit <= 0 || len(list) <= limit {
+		slices.SortFunc(list, compareKeys)
+		return list, ""
+	}
+	// A entrada a mais só serve para dar o início da próxima página
+	page = smallestKeys(list, limit+1)
+	return page[:limit], page[limit].Key
+}
+
+// smallestKeys returns the n entries of list with the smallest keys, in
+// key order. It keeps them in a max-heap, so the cost is O(len(list) log n)
+// rather than a sort of the whole list.
+func smallestKeys(list []kvEntry, n int) []kvEntry {
+	h := make(keyHeap, 0, n)
+	for _, e := range list {
+		switch {
+		case len(h) < n:
+			heap.Push(&h, e)
+		case e.Key < h[0].Key:
+			h[0] = e
+			heap.Fix(&h, 0)
+		}
+	}
+	slices.SortFunc(h, compareKeys)
+	return h
+}
+
+// keyHeap is a max-heap of entries by key.
+type keyHeap []kvEntry
+
+func (h keyHeap) Len() int           { return len(h) }
+func (h keyHeap) Less(i, j int) bool { return h[i].Key > h[j].Key }
+func (h keyHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *keyHeap) Push(x any)        { *h = append(*h, x.(kvEntry)) }
+func (h *keyHeap) Pop() any {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
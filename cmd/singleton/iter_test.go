@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestScanPages(t *testing.T) {
+	s := newSingleton()
+	var keys []string
+	for _, i := range rand.Perm(200) {
+		k := fmt.Sprintf("k%03d", i)
+		s.Set(k, "v"+k)
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	tests := []struct {
+		start, end string
+		limit      int
+	}{
+		{"", "", 0},
+		{"", "", 1},
+		{"", "", 7},
+		{"", "", 200},
+		{"", "", 500},
+		{"k050", "k150", 9},
+		{"k050", "k150", 100},
+		{"k0505", "", 13}, // Início que não é uma chave
+		{"z", "", 5},
+		{"k100", "k100", 5},
+	}
+	for _, tt := range tests {
+		var want []string
+		for _, k := range keys {
+			if k >= tt.start && (tt.end == "" || k < tt.end) {
+				want = append(want, k)
+			}
+		}
+
+		var got []string
+		start, pages := tt.start, 0
+		for {
+			page, next := s.Scan(start, tt.end, tt.limit)
+			if tt.limit > 0 && len(page) > tt.limit {
+				t.Fatalf("%+v: page of %d entries", tt, len(page))
+			}
+			for _, e := range page {
+				if e.Value != "v"+e.Key {
+					t.Fatalf("%+v: %s = %q", tt, e.Key, e.Value)
+				}
+				got = append(got, e.Key)
+			}
+			if pages++; next == "" || pages > len(keys) {
+				break
+			}
+			start = next
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("%+v: scanned %d keys %q, want %d", tt, len(got), got, len(want))
+		}
+	}
+}
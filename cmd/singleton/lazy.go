@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Lazy is a fallible alternative to the sync.Once of
+// GetInstance_example_3: init may return an error (or panic), and a
+// failure is never cached, so a later Get tries again.
+//
+// A failed init is retried with exponential backoff until it succeeds,
+// the attempts run out or every caller waiting for it has given up.
+// Concurrent callers share one in-flight initialization.
+type Lazy[T any] struct {
+	init        func(ctx context.Context) (T, error)
+	attempts    int
+	baseBackoff time.Duration
+	maxBackoff  time.Duration
+
+	mu      sync.Mutex
+	done    bool
+	value   T
+	pending *lazyCall[T]
+}
+
+type lazyCall[T any] struct {
+	finished chan struct{}
+	cancel   context.CancelFunc
+	waiters  int // Gets esperando esta inicialização; protegido por Lazy.mu
+	value    T
+	err      error
+}
+
+// LazyOption configures a Lazy.
+type LazyOption func(*lazyConfig)
+
+type lazyConfig struct {
+	attempts    int
+	baseBackoff time.Duration
+	maxBackoff  time.Duration
+}
+
+// WithRetry sets how many times one Get runs init (default 3) and the
+// first backoff between attempts, doubled after each failure up to max.
+func WithRetry(attempts int, base, max time.Duration) LazyOption {
+	return func(c *lazyConfig) {
+		c.attempts, c.baseBackoff, c.maxBackoff = attempts, base, max
+	}
+}
+
+func NewLazy[T any](init func(ctx context.Context) (T, error), opts ...LazyOption) *Lazy[T] {
+	cfg := lazyConfig{attempts: 3, baseBackoff: 50 * time.Millisecond, maxBackoff: 2 * time.Second}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.attempts < 1 {
+		cfg.attempts = 1
+	}
+	return &Lazy[T]{
+		init:        init,
+		attempts:    cfg.attempts,
+		baseBackoff: cfg.baseBackoff,
+		maxBackoff:  cfg.maxBackoff,
+	}
+}
+
+// Get returns the value, running init if no earlier call succeeded.
+// When ctx ends first, Get returns ctx.Err(). init gets a context of its
+// own, with the values of the ctx that started it but none of its
+// deadline: it is cancelled only when every Get waiting for it has
+// returned this way, and the next Get then starts over.
+func (l *Lazy[T]) Get(ctx context.Context) (T, error) {
+	l.mu.Lock()
+	if l.done {
+		l.mu.Unlock()
+		return l.value, nil
+	}
+	call := l.pending
+	if call == nil {
+		initCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		call = &lazyCall[T]{finished: make(chan struct{}), cancel: cancel}
+		l.pending = call
+		go l.run(initCtx, call)
+	}
+	call.waiters++
+	l.mu.Unlock()
+
+	select {
+	case <-call.finished:
+		return call.value, call.err
+	case <-ctx.Done():
+		l.leave(call)
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// leave drops a Get that gave up on call. The last one to leave cancels
+// the initialization.
+func (l *Lazy[T]) leave(call *lazyCall[T]) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if call.waiters--; call.waiters == 0 {
+		call.cancel()
+		if l.pending == call {
+			l.pending = nil
+		}
+	}
+}
+
+// Reset forgets a successful value so the next Get initializes again.
+func (l *Lazy[T]) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var zero T
+	l.done, l.value = false, zero
+}
+
+func (l *Lazy[T]) run(ctx context.Context, call *lazyCall[T]) {
+	defer call.cancel()
+
+	backoff := l.baseBackoff
+	attempt := 1
+	for ; ; attempt++ {
+		call.value, call.err = l.attempt(ctx)
+		if call.err == nil || attempt == l.attempts {
+			break
+		}
+		if call.err = sleepCtx(ctx, backoff); call.err != nil {
+			break
+		}
+		backoff = min(backoff*2, l.maxBackoff)
+	}
+	switch {
+	case call.err == nil:
+	case attempt == 1:
+		call.err = fmt.Errorf("lazy: init failed: %w", call.err)
+	default:
+		call.err = fmt.Errorf("lazy: init failed after %d attempts: %w", attempt, call.err)
+	}
+
+	l.mu.Lock()
+	// Uma chamada abandonada pode terminar depois de outra mais nova
+	if call.err == nil && !l.done {
+		l.done, l.value = true, call.value
+	}
+	if l.pending == call {
+		l.pending = nil
+	}
+	l.mu.Unlock()
+	close(call.finished)
+}
+
+// attempt runs init once, turning a panic into an error.
+func (l *Lazy[T]) attempt(ctx context.Context) (value T, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("lazy: init panicked: %v", r)
+		}
+	}()
+	if err := ctx.Err(); err != nil {
+		return value, err
+	}
+	return l.init(ctx)
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLazyRetry(t *testing.T) {
+	var calls atomic.Int32
+	l := NewLazy(func(ctx context.Context) (int, error) {
+		if calls.Add(1) < 3 {
+			return 0, errors.New("indisponível")
+		}
+		return 42, nil
+	}, WithRetry(3, time.Millisecond, time.Millisecond))
+	if v, err := l.Get(context.Background()); err != nil || v != 42 {
+		t.Fatalf("Get = %d, %v; want 42", v, err)
+	}
+	if v, _ := l.Get(context.Background()); v != 42 || calls.Load() != 3 {
+		t.Errorf("second Get = %d after %d calls, want the cached 42 after 3", v, calls.Load())
+	}
+
+	boom := errors.New("boom")
+	tests := []struct {
+		attempts int
+		msg      string
+	}{
+		{1, "lazy: init failed: boom"},
+		{2, "lazy: init failed after 2 attempts: boom"},
+	}
+	for _, tt := range tests {
+		calls.Store(0)
+		l := NewLazy(func(ctx context.Context) (int, error) {
+			calls.Add(1)
+			return 0, boom
+		}, WithRetry(tt.attempts, time.Millisecond, time.Millisecond))
+		_, err := l.Get(context.Background())
+		if !errors.Is(err, boom) || err.Error() != tt.msg {
+			t.Errorf("attempts %d: err = %v, want %q", tt.attempts, err, tt.msg)
+		}
+		// A falha não fica em cache
+		l.Get(context.Background())
+		if n := calls.Load(); n != int32(2*tt.attempts) {
+			t.Errorf("attempts %d: init ran %d times over two Gets", tt.attempts, n)
+		}
+	}
+
+	l = NewLazy(func(ctx context.Context) (int, error) { panic("pânico") }, WithRetry(1, 0, 0))
+	if _, err := l.Get(context.Background()); err == nil || !strings.Contains(err.Error(), "panicked") {
+		t.Errorf("panicking init: err = %v", err)
+	}
+}
+
+func TestLazyConcurrentFirstCall(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	l := NewLazy(func(ctx context.Context) (*int, error) {
+		calls.Add(1)
+		<-release
+		n := 7
+		return &n, nil
+	})
+
+	var wg sync.WaitGroup
+	results := make([]*int, 16)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := l.Get(context.Background())
+			if err != nil {
+				t.Error(err)
+			}
+			results[i] = v
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if n := calls.Load(); n != 1 {
+		t.Errorf("init ran %d times, want 1", n)
+	}
+	for _, v := range results {
+		if v != results[0] {
+			t.Fatal("callers got different values")
+		}
+	}
+}
+
+func TestLazyCancellation(t *testing.T) {
+	var calls atomic.Int32
+	canceled := make(chan struct{}, 2)
+	l := NewLazy(func(ctx context.Context) (int, error) {
+		if calls.Add(1) > 1 {
+			return 1, nil
+		}
+		<-ctx.Done()
+		canceled <- struct{}{}
+		return 0, ctx.Err()
+	}, WithRetry(1, 0, 0))
+
+	ctxA, cancelA := context.WithCancel(context.Background())
+	ctxB, cancelB := context.WithCancel(context.Background())
+	errs := make(chan error, 2)
+	for _, ctx := range []context.Context{ctxA, ctxB} {
+		go func() {
+			_, err := l.Get(ctx)
+			errs <- err
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	// Um chamador desistir não cancela o init dos outros
+	cancelA()
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Errorf("first Get = %v, want context.Canceled", err)
+	}
+	select {
+	case <-canceled:
+		t.Fatal("init canceled while a caller still waits")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	cancelB()
+	<-errs
+	select {
+	case <-canceled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("init not canceled after every caller left")
+	}
+	// O próximo Get começa uma inicialização nova
+	if v, err := l.Get(context.Background()); err != nil || v != 1 {
+		t.Errorf("Get after cancellation = %d, %v; want 1", v, err)
+	}
+}
+
+func TestLazyFirstDeadlineDoesNotBindOthers(t *testing.T) {
+	var calls atomic.Int32
+	l := NewLazy(func(ctx context.Context) (int, error) {
+		calls.Add(1)
+		select {
+		case <-time.After(30 * time.Millisecond):
+			return 5, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}, WithRetry(1, 0, 0))
+
+	short, cancel := context.WithTimeout(context.Background(), 15*time.Millisecond)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		_, err := l.Get(short)
+		done <- err
+	}()
+	time.Sleep(5 * time.Millisecond)
+	if v, err := l.Get(context.Background()); err != nil || v != 5 {
+		t.Errorf("later Get = %d, %v; want 5", v, err)
+	}
+	if err := <-done; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("short Get = %v, want DeadlineExceeded", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("init ran %d times, want the later Get to share the first one", n)
+	}
+}
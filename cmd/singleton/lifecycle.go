@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Lifecycle is implemented by components whose resources must be
+// acquired before use and released on shutdown.
+type Lifecycle interface {
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+}
+
+var (
+	ErrUnknownComponent   = errors.New("lifecycle: unknown component")
+	ErrDuplicateComponent = errors.New("lifecycle: component already added")
+	ErrComponentCycle     = errors.New("lifecycle: dependency cycle")
+)
+
+// ComponentError is the failure of a single component.
+type ComponentError struct {
+	Name string
+	Err  error
+}
+
+func (e ComponentError) Error() string { return e.Name + ": " + e.Err.Error() }
+func (e ComponentError) Unwrap() error { return e.Err }
+
+// StopError lists every component that failed to stop, in stop order.
+// Late is the context's error when it ended before the last component
+// had stopped: those components were still stopped, after the deadline.
+type StopError struct {
+	Failed []ComponentError
+	Late   error
+}
+
+func (e *StopError) Error() string {
+	msgs := make([]string, 0, len(e.Failed)+1)
+	for _, f := range e.Failed {
+		msgs = append(msgs, f.Error())
+	}
+	if e.Late != nil {
+		msgs = append(msgs, "stopped after the deadline: "+e.Late.Error())
+	}
+	return "lifecycle: failed to stop: " + strings.Join(msgs, "; ")
+}
+
+func (e *StopError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failed)+1)
+	for _, f := range e.Failed {
+		errs = append(errs, f)
+	}
+	if e.Late != nil {
+		errs = append(errs, e.Late)
+	}
+	return errs
+}
+
+type component struct {
+	name string
+	c    Lifecycle
+	deps []string
+}
+
+// LifecycleManager starts components after their dependencies and stops
+// them in the reverse order.
+type LifecycleManager struct {
+	mu         sync.Mutex
+	components map[string]*component
+	names      []string     // Ordem de inclusão, para um start determinístico
+	started    []*component // Ordem em que foram iniciados
+}
+
+func NewLifecycleManager() *LifecycleManager {
+	return &LifecycleManager{components: make(map[string]*component)}
+}
+
+// Add registers c under name. dependsOn names components that must be
+// running before c starts and must stop only after c has stopped.
+func (m *LifecycleManager) Add(name string, c Lifecycle, dependsOn ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.components[name]; ok {
+		return fmt.Errorf("%w: %q", ErrDuplicateComponent, name)
+	}
+	m.components[name] = &component{name: name, c: c, deps: append([]string(nil), dependsOn...)}
+	m.names = append(m.names, name)
+	return nil
+}
+
+// Start starts every component in dependency order. If one fails, the
+// ones already started are stopped again and the start error returned.
+func (m *LifecycleManager) Start(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	order, err := dependencyOrder(m.names, func(n string) (*component, []string, bool) {
+		c, ok := m.components[n]
+		if !ok {
+			return nil, nil, false
+		}
+		return c, c.deps, true
+	}, ErrUnknownComponent, ErrComponentCycle)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range order {
+		if err := c.c.Start(ctx); err != nil {
+			startErr := ComponentError{Name: c.name, Err: err}
+			if stopErr := m.stopLocked(ctx); stopErr != nil {
+				return errors.Join(startErr, stopErr)
+			}
+			return startErr
+		}
+		m.started = append(m.started, c)
+	}
+	return nil
+}
+
+// Stop calls Stop on every started component in reverse start order,
+// each after the previous one returned, even if an earlier one failed or
+// ctx has ended: a skipped Stop could leave a log unsynced. ctx tells the
+// components how long they have, so a component that ignores it delays
+// the whole shutdown. The result is nil or a *StopError, whose Late field
+// reports a shutdown that outlived ctx.
+func (m *LifecycleManager) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.stopLocked(ctx)
+}
+
+func (m *LifecycleManager) stopLocked(ctx context.Context) error {
+	var failed []ComponentError
+	for i := len(m.started) - 1; i >= 0; i-- {
+		c := m.started[i]
+		if err := c.c.Stop(ctx); err != nil {
+			failed = append(failed, ComponentError{Name: c.name, Err: err})
+		}
+	}
+	m.started = nil
+	if late := ctx.Err(); len(failed) > 0 || late != nil {
+		return &StopError{Failed: failed, Late: late}
+	}
+	return nil
+}
+
+// Run starts every component, waits for SIGINT, SIGTERM or the end of ctx
+// and then stops them, giving the components timeout to shut down.
+func (m *LifecycleManager) Run(ctx context.Context, timeout time.Duration) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := m.Start(ctx); err != nil {
+		return err
+	}
+	<-ctx.Done()
+	log.Printf("lifecycle: desligando (%v)", context.Cause(ctx))
+
+	stopCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+	return m.Stop(stopCtx)
+}
+
+// defaultJanitorInterval is how often a started singleton sweeps
+// expired keys.
+const defaultJanitorInterval = time.Second
+
+// Start makes the singleton a Lifecycle component: it starts the expiry
+// janitor.
+func (s *singleton) Start(ctx context.Context) error {
+	s.StartJanitor(defaultJanitorInterval)
+	return nil
+}
+
+// Stop releases everything the singleton may be running: the janitor,
+// the auto-snapshot (after its final save) and the write-ahead log.
+func (s *singleton) Stop(ctx context.Context) error {
+	s.StopJanitor()
+	return errors.Join(s.StopAutoSnapshot(), s.DisableDurability())
+}
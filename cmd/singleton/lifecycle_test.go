@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeComponent records its Start and Stop calls in a shared log.
+type fakeComponent struct {
+	name     string
+	log      *eventLog
+	startErr error
+	stopErr  error
+	stopFor  time.Duration // Quanto Stop demora, ignorando o ctx
+}
+
+type eventLog struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (l *eventLog) add(e string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, e)
+}
+
+func (l *eventLog) all() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return slices.Clone(l.events)
+}
+
+func (f *fakeComponent) Start(ctx context.Context) error {
+	f.log.add("start " + f.name)
+	return f.startErr
+}
+
+func (f *fakeComponent) Stop(ctx context.Context) error {
+	time.Sleep(f.stopFor)
+	f.log.add("stop " + f.name)
+	return f.stopErr
+}
+
+func TestLifecycleOrder(t *testing.T) {
+	log := new(eventLog)
+	m := NewLifecycleManager()
+	// Incluídos fora de ordem: as dependências decidem
+	m.Add("server", &fakeComponent{name: "server", log: log}, "cache", "db")
+	m.Add("cache", &fakeComponent{name: "cache", log: log}, "db")
+	m.Add("db", &fakeComponent{name: "db", log: log})
+
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"start db", "start cache", "start server", "stop server", "stop cache", "stop db"}
+	if got := log.all(); !slices.Equal(got, want) {
+		t.Errorf("events = %q, want %q", got, want)
+	}
+
+	// Um segundo Stop não para nada de novo
+	if err := m.Stop(context.Background()); err != nil || len(log.all()) != len(want) {
+		t.Errorf("second Stop = %v, events %q", err, log.all())
+	}
+}
+
+func TestLifecyclePartialStart(t *testing.T) {
+	log := new(eventLog)
+	boom := errors.New("boom")
+	m := NewLifecycleManager()
+	m.Add("db", &fakeComponent{name: "db", log: log})
+	m.Add("cache", &fakeComponent{name: "cache", log: log, stopErr: errors.New("flush")}, "db")
+	m.Add("server", &fakeComponent{name: "server", log: log, startErr: boom}, "cache")
+	m.Add("metrics", &fakeComponent{name: "metrics", log: log}, "server")
+
+	err := m.Start(context.Background())
+	var ce ComponentError
+	if !errors.As(err, &ce) || ce.Name != "server" || !errors.Is(err, boom) {
+		t.Fatalf("Start = %v, want the server's error", err)
+	}
+	var se *StopError
+	if !errors.As(err, &se) || len(se.Failed) != 1 || se.Failed[0].Name != "cache" {
+		t.Errorf("Start = %v, want the cache's stop error joined", err)
+	}
+	// Só o que chegou a iniciar é parado, na ordem inversa
+	want := []string{"start db", "start cache", "start server", "stop cache", "stop db"}
+	if got := log.all(); !slices.Equal(got, want) {
+		t.Errorf("events = %q, want %q", got, want)
+	}
+}
+
+func TestLifecycleStopTimeout(t *testing.T) {
+	log := new(eventLog)
+	m := NewLifecycleManager()
+	m.Add("store", &fakeComponent{name: "store", log: log})
+	m.Add("slow", &fakeComponent{name: "slow", log: log, stopFor: 50 * time.Millisecond}, "store")
+	m.Add("server", &fakeComponent{name: "server", log: log}, "slow")
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := m.Stop(ctx)
+	var se *StopError
+	if !errors.As(err, &se) || len(se.Failed) != 0 || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Stop = %v, want only a late shutdown reported", err)
+	}
+	// O prazo estourado não pula o Stop de quem vem depois
+	want := []string{"stop server", "stop slow", "stop store"}
+	if got := log.all()[3:]; !slices.Equal(got, want) {
+		t.Errorf("stops = %q, want %q", got, want)
+	}
+}
+
+func TestLifecycleInvalidGraph(t *testing.T) {
+	log := new(eventLog)
+	m := NewLifecycleManager()
+	m.Add("a", &fakeComponent{name: "a", log: log}, "b")
+	m.Add("b", &fakeComponent{name: "b", log: log}, "a")
+	if err := m.Start(context.Background()); !errors.Is(err, ErrComponentCycle) {
+		t.Errorf("Start = %v, want ErrComponentCycle", err)
+	}
+	if err := m.Add("a", &fakeComponent{}); !errors.Is(err, ErrDuplicateComponent) {
+		t.Errorf("Add = %v, want ErrDuplicateComponent", err)
+	}
+
+	m = NewLifecycleManager()
+	m.Add("a", &fakeComponent{name: "a", log: log}, "missing")
+	if err := m.Start(context.Background()); !errors.Is(err, ErrUnknownComponent) {
+		t.Errorf("Start = %v, want ErrUnknownComponent", err)
+	}
+	if got := log.all(); len(got) != 0 {
+		t.Errorf("events = %q, want nothing started", got)
+	}
+}
@@ -1,12 +1,43 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
 
 func main() {
+	// Subcomandos: "serve" expõe o store via HTTP, "resp" via protocolo
+	// Redis e "benchtable" tabula resultados de benchmark; sem argumentos
+	// roda a demo
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1], os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	runDemo()
+}
+
+func runCommand(name string, args []string) error {
+	switch name {
+	case "serve":
+		return runHTTPServer(args)
+	case "resp":
+		return runRESPServer(args)
+	case "benchtable":
+		return runBenchTable(args)
+	}
+	return fmt.Errorf("comando desconhecido: %q (use: serve, resp, benchtable)", name)
+}
+
+func runDemo() {
 	fmt.Println("=== Teste Singleton com Struct ===")
 
 	// Teste básico - todas devem ser a mesma instância
@@ -73,5 +104,211 @@ func main() {
 	}
 	wg.Wait()
 
+	fmt.Println("\n=== Teste do Container de Injeção ===")
+	// Daqui em diante o cache não vem de GetInstance_example_N: o container
+	// constrói um só e o entrega a quem o declara no construtor
+	type config struct{ MaxEntries int }
+	type servicoUsuarios struct{ store kvStore }
+	type requisicao struct{ ID int }
+	type handler struct {
+		usuarios *servicoUsuarios
+		req      *requisicao
+	}
+	container := NewContainer()
+	proximaRequisicao := 0
+	for _, err := range []error{
+		container.Provide(LifetimeSingleton, func() config { return config{MaxEntries: 100} }),
+		container.Provide(LifetimeSingleton, func(cfg config) *singleton {
+			return newSingleton(WithMaxEntries(cfg.MaxEntries))
+		}),
+		Bind[kvStore, *singleton](container),
+		container.Provide(LifetimeSingleton, func(store kvStore) *servicoUsuarios { return &servicoUsuarios{store: store} }),
+		container.Provide(LifetimeScoped, func() *requisicao {
+			proximaRequisicao++
+			return &requisicao{ID: proximaRequisicao}
+		}),
+		container.Provide(LifetimeTransient, func(u *servicoUsuarios, req *requisicao) *handler {
+			return &handler{usuarios: u, req: req}
+		}),
+		container.Validate(),
+	} {
+		if err != nil {
+			fmt.Printf("Erro de configuração: %v\n", err)
+		}
+	}
+	for i := 1; i <= 2; i++ {
+		escopo := container.NewScope()
+		h1, _ := Resolve[*handler](escopo)
+		h2, _ := Resolve[*handler](escopo)
+		h1.usuarios.store.Set(fmt.Sprintf("usuario:%d", i), "ok")
+		fmt.Printf("Escopo %d: handlers %p != %p, requisição %d == %d, cache com %d chaves\n",
+			i, h1, h2, h1.req.ID, h2.req.ID, h1.usuarios.store.Size())
+	}
+	cache, err := Resolve[*singleton](container)
+	if err != nil {
+		fmt.Printf("Erro ao resolver o cache: %v\n", err)
+		return
+	}
+	store, _ := Resolve[kvStore](container)
+	fmt.Printf("Cache do container: %p, o mesmo visto pelos handlers: %t\n", cache, store == kvStore(cache))
+	_, err = Resolve[*handler](container)
+	fmt.Printf("Fora de um escopo: %v\n", err)
+	ciclo := NewContainer()
+	ciclo.Provide(LifetimeSingleton, func(r *requisicao) *servicoUsuarios { return &servicoUsuarios{} })
+	ciclo.Provide(LifetimeSingleton, func(u *servicoUsuarios) *requisicao { return &requisicao{} })
+	fmt.Printf("Ciclo detectado: %v\n", ciclo.Validate())
+	cativo := NewContainer()
+	cativo.Provide(LifetimeScoped, func() *requisicao { return &requisicao{} })
+	cativo.Provide(LifetimeSingleton, func(r *requisicao) *servicoUsuarios { return &servicoUsuarios{} })
+	fmt.Printf("Singleton preso a um escopo: %v\n", cativo.Validate())
+
+	fmt.Println("\n=== Teste de Expiração (TTL) ===")
+	cache.StartJanitor(10 * time.Millisecond)
+	cache.SetWithTTL("sessao", "abc123", 50*time.Millisecond)
+	sessao, exists := cache.Get("sessao")
+	fmt.Printf("Sessão: %s (existe: %t), Tamanho: %d\n", sessao, exists, cache.Size())
+	time.Sleep(100 * time.Millisecond)
+	_, exists = cache.Get("sessao")
+	fmt.Printf("Sessão após TTL (existe: %t), Tamanho: %d\n", exists, cache.Size())
+	cache.StopJanitor()
+
+	fmt.Println("\n=== Teste de Capacidade (LRU) ===")
+	lru := newSingleton(
+		WithMaxEntries(2),
+		WithEvictionPolicy(NewLRUPolicy[string]()),
+		WithOnEvict(func(key, value string) {
+			fmt.Printf("Removido por capacidade: %s=%s\n", key, value)
+		}),
+	)
+	lru.Set("a", "1")
+	lru.Set("b", "2")
+	lru.Get("a") // "a" passa a ser a mais recente
+	lru.Set("c", "3")
+	fmt.Printf("Tamanho do cache: %d\n", lru.Size())
+
+	fmt.Println("\n=== Teste do Store Genérico ===")
+	type usuario struct {
+		Nome  string
+		Idade int
+	}
+	usuarios := GetStore[int, usuario]()
+	usuarios.Set(1, usuario{Nome: "Maria", Idade: 28})
+	u, exists := GetStore[int, usuario]().Get(1)
+	fmt.Printf("Usuário 1: %+v (existe: %t), mesma instância: %t\n", u, exists, usuarios == GetStore[int, usuario]())
+
+	fmt.Println("\n=== Teste de Snapshot ===")
+	var snapshot bytes.Buffer
+	if err := cache.SaveSnapshot(&snapshot); err != nil {
+		fmt.Printf("Erro ao salvar snapshot: %v\n", err)
+	}
+	restaurado := newSingleton()
+	if err := restaurado.LoadSnapshot(&snapshot); err != nil {
+		fmt.Printf("Erro ao carregar snapshot: %v\n", err)
+	}
+	usuario1, _ := restaurado.Get("usuario:1")
+	fmt.Printf("Restaurado: %d chaves, usuario:1=%s\n", restaurado.Size(), usuario1)
+
+	fmt.Println("\n=== Teste de Watch ===")
+	eventos, cancelar := cache.Watch("config:")
+	cache.Set("config:modo", "producao")
+	cache.Set("config:modo", "debug")
+	cache.Delete("config:modo")
+	cancelar()
+	for ev := range eventos {
+		fmt.Printf("Evento %s em %s: %q -> %q\n", ev.Kind, ev.Key, ev.OldValue, ev.NewValue)
+	}
+
+	fmt.Println("\n=== Teste de Update Atômico ===")
+	wg = sync.WaitGroup{}
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Get + Set separados perderiam incrementos; Update não
+			cache.Update("contador", func(old string, ok bool) (string, bool) {
+				n, _ := strconv.Atoi(old)
+				return strconv.Itoa(n + 1), true
+			})
+		}()
+	}
+	wg.Wait()
+	contador, _ := cache.Get("contador")
+	fmt.Printf("Contador após 100 goroutines: %s\n", contador)
+
+	fmt.Println("\n=== Teste do Registry ===")
+	Register("cache", func(*Resolution) (*singleton, error) {
+		return newSingleton(WithMaxEntries(100)), nil
+	})
+	Register("sessoes", func(*Resolution) (*singleton, error) {
+		return newSingleton(), nil
+	})
+	Register("usuarios", func(res *Resolution) (*Store[int, string], error) {
+		cache, err := GetWithin[*singleton](res, "cache")
+		if err != nil {
+			return nil, err
+		}
+		cache.Set("usuarios:carregado", "true")
+		return NewStore[int, string](), nil
+	}, "cache")
+	cacheA, _ := Get[*singleton]("cache")
+	cacheB, _ := Get[*singleton]("cache")
+	sessoes, _ := Get[*singleton]("sessoes")
+	_, err = Get[*Store[int, string]]("usuarios")
+	fmt.Printf("cache: %p == %p, sessoes: %p, erro: %v\n", cacheA, cacheB, sessoes, err)
+	Register("a", func(*Resolution) (int, error) { return 1, nil }, "b")
+	Register("b", func(*Resolution) (int, error) { return 2, nil }, "a")
+	_, err = Get[int]("a")
+	fmt.Printf("Ciclo detectado: %v\n", err)
+
+	fmt.Println("\n=== Teste de Inicialização Lazy com Retry ===")
+	tentativas := 0
+	conexao := NewLazy(func(ctx context.Context) (*singleton, error) {
+		tentativas++
+		if tentativas < 3 {
+			return nil, fmt.Errorf("banco indisponível (tentativa %d)", tentativas)
+		}
+		return newSingleton(), nil
+	}, WithRetry(5, 10*time.Millisecond, 100*time.Millisecond))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	db, err := conexao.Get(ctx)
+	cancel()
+	fmt.Printf("Instância: %p após %d tentativas, erro: %v\n", db, tentativas, err)
+
+	fmt.Println("\n=== Teste de Iteração Ordenada ===")
+	ordenado := newSingleton()
+	for _, key := range []string{"user:3", "user:1", "order:7", "user:2", "user:4"} {
+		ordenado.Set(key, strings.ToUpper(key))
+	}
+	for key, value := range ordenado.All() {
+		fmt.Printf("%s=%s ", key, value)
+	}
+	fmt.Println()
+	fmt.Printf("Keys(\"user:\"): %v\n", ordenado.Keys("user:"))
+	for cursor, pagina := "user:", 1; ; pagina++ {
+		page, next := ordenado.Scan(cursor, "user;", 2)
+		fmt.Printf("Página %d: %v (próximo: %q)\n", pagina, page, next)
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	fmt.Println("\n=== Teste de Namespaces ===")
+	compartilhado := newSingleton()
+	usuariosNS, _ := compartilhado.Namespace("usuarios", WithNamespaceMaxEntries(2))
+	sessoesNS, _ := compartilhado.Namespace("sessoes", WithDefaultTTL(time.Minute))
+	if _, err := compartilhado.Namespace("a:b"); err != nil {
+		fmt.Printf("Nome inválido: %v\n", err)
+	}
+	usuariosNS.Set("1", "Maria")
+	usuariosNS.Set("2", "João")
+	err = usuariosNS.Set("3", "Ana")
+	fmt.Printf("Terceiro usuário: %v\n", err)
+	sessoesNS.Set("1", "token-da-maria") // Mesma chave "1", sem colisão
+	ttl, _ := compartilhado.TTL("sessoes:1")
+	fmt.Printf("Chaves no store: %v, TTL da sessão: %v\n", compartilhado.Keys(""), ttl.Round(time.Minute))
+	fmt.Printf("Removidas ao derrubar sessoes: %d, restam: %v\n", sessoesNS.Drop(), compartilhado.Keys(""))
+	fmt.Printf("Stats de usuarios: %+v\n", usuariosNS.Stats())
+
 	fmt.Println("=== Fim dos Testes ===")
 }
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+type storeOp int
+
+const (
+	opGet storeOp = iota
+	opSet
+	opDelete
+	opTxn
+	numStoreOps
+)
+
+var storeOpNames = [numStoreOps]string{"get", "set", "delete", "txn"}
+
+// latencyBuckets are the upper bounds of the latency histograms, from
+// 1µs to ~1s in powers of four.
+var latencyBuckets = [...]time.Duration{
+	time.Microsecond,
+	4 * time.Microsecond,
+	16 * time.Microsecond,
+	64 * time.Microsecond,
+	256 * time.Microsecond,
+	time.Millisecond,
+	4 * time.Millisecond,
+	16 * time.Millisecond,
+	64 * time.Millisecond,
+	256 * time.Millisecond,
+	time.Second,
+}
+
+// histogram is a lock-free latency histogram. counts has one slot per
+// bucket plus a last one for +Inf; they are not cumulative.
+type histogram struct {
+	counts [len(latencyBuckets) + 1]atomic.Uint64
+	sum    atomic.Int64 // nanossegundos
+}
+
+func (h *histogram) observe(d time.Duration) {
+	i := 0
+	for i < len(latencyBuckets) && d > latencyBuckets[i] {
+		i++
+	}
+	h.counts[i].Add(1)
+	h.sum.Add(int64(d))
+}
+
+// storeMetrics holds the counters of a Store. All fields are updated with
+// atomics so reads never take the store lock.
+type storeMetrics struct {
+	hits        atomic.Uint64
+	misses      atomic.Uint64
+	sets        atomic.Uint64
+	deletes     atomic.Uint64
+	evictions   atomic.Uint64
+	expirations atomic.Uint64
+
+	// Medir latência custa dois time.Now por operação: só com timed ligado
+	timed   atomic.Bool
+	latency [numStoreOps]histogram
+}
+
+// start returns the time an operation starts, or the zero time when
+// latency tracking is off so observe skips it.
+func (m *storeMetrics) start() time.Time {
+	if !m.timed.Load() {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+func (m *storeMetrics) observe(op storeOp, start time.Time) {
+	if start.IsZero() {
+		return
+	}
+	m.latency[op].observe(time.Since(start))
+}
+
+func (m *storeMetrics) removed(kind EventKind) {
+	switch kind {
+	case EventDelete:
+		m.deletes.Add(1)
+	case EventEvict:
+		m.evictions.Add(1)
+	case EventExpire:
+		m.expirations.Add(1)
+	}
+}
+
+// LatencyStats is a snapshot of one operation histogram. Buckets[i]
+// counts the operations that took at most Bounds[i], cumulatively.
+type LatencyStats struct {
+	Bounds  []time.Duration `json:"bounds"`
+	Buckets []uint64        `json:"buckets"`
+	Count   uint64          `json:"count"`
+	Sum     time.Duration   `json:"sum"`
+}
+
+// Stats is a point-in-time copy of the store counters. Latency is nil
+// unless latency tracking is on.
+type Stats struct {
+	Hits        uint64                  `json:"hits"`
+	Misses      uint64                  `json:"misses"`
+	Sets        uint64                  `json:"sets"`
+	Deletes     uint64                  `json:"deletes"`
+	Evictions   uint64                  `json:"evictions"`
+	Expirations uint64                  `json:"expirations"`
+	Size        int                     `json:"size"`
+	Latency     map[string]LatencyStats `json:"latency"`
+}
+
+// HitRatio returns hits / (hits + misses), or 0 before the first read.
+func (st Stats) HitRatio() float64 {
+	total := st.Hits + st.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(st.Hits) / float64(total)
+}
+
+// SetLatencyTracking turns the per-operation latency histograms on or
+// off. They are off by default because timing every Get and Set costs
+// more than the operation on a small store.
+func (s *Store[K, V]) SetLatencyTracking(on bool) {
+	s.metrics.timed.Store(on)
+}
+
+// Stats returns the current counters and size, plus the latency
+// histograms when latency tracking is on.
+func (s *Store[K, V]) Stats() Stats {
+	m := &s.metrics
+	st := Stats{
+		Hits:        m.hits.Load(),
+		Misses:      m.misses.Load(),
+		Sets:        m.sets.Load(),
+		Deletes:     m.deletes.Load(),
+		Evictions:   m.evictions.Load(),
+		Expirations: m.expirations.Load(),
+		Size:        s.Size(),
+	}
+	if !m.timed.Load() {
+		return st
+	}
+	st.Latency = make(map[string]LatencyStats, numStoreOps)
+	for op := range numStoreOps {
+		h := &m.latency[op]
+		ls := LatencyStats{
+			Bounds:  latencyBuckets[:],
+			Buckets: make([]uint64, len(latencyBuckets)),
+			Sum:     time.Duration(h.sum.Load()),
+		}
+		var cumulative uint64
+		for i := range latencyBuckets {
+			cumulative += h.counts[i].Load()
+			ls.Buckets[i] = cumulative
+		}
+		ls.Count = cumulative + h.counts[len(latencyBuckets)].Load()
+		st.Latency[storeOpNames[op]] = ls
+	}
+	return st
+}
+
+// Stats adds up the counters of every shard.
+func (s *ShardedStore[K, V]) Stats() Stats {
+	var total Stats
+	for i, shard := range s.shards {
+		st := shard.Stats()
+		if i == 0 {
+			total = st
+			continue
+		}
+		total.Hits += st.Hits
+		total.Misses += st.Misses
+		total.Sets += st.Sets
+		total.Deletes += st.Deletes
+		total.Evictions += st.Evictions
+		total.Expirations += st.Expirations
+		total.Size += st.Size
+		if total.Latency == nil {
+			total.Latency = st.Latency
+			continue
+		}
+		for name, ls := range st.Latency {
+			acc := total.Latency[name]
+			buckets := make([]uint64, len(acc.Buckets))
+			for j := range buckets {
+				buckets[j] = acc.Buckets[j] + ls.Buckets[j]
+			}
+			acc.Buckets = buckets
+			acc.Count += ls.Count
+			acc.Sum += ls.Sum
+			total.Latency[name] = acc
+		}
+	}
+	return total
+}
+
+// newMetricsHandler renders stats() in the Prometheus text exposition
+// format, with every metric name prefixed by namespace.
+func newMetricsHandler(namespace string, stats func() Stats) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		writePrometheus(w, namespace, stats())
+	})
+}
+
+func writePrometheus(w io.Writer, ns string, st Stats) {
+	counter := func(name, help string, v uint64) {
+		fmt.Fprintf(w, "# HELP %s_%s %s\n# TYPE %s_%s counter\n%s_%s %d\n", ns, name, help, ns, name, ns, name, v)
+	}
+	counter("hits_total", "Reads that found a live key.", st.Hits)
+	counter("misses_total", "Reads that found no live key.", st.Misses)
+	counter("sets_total", "Entries written.", st.Sets)
+	counter("deletes_total", "Entries removed explicitly.", st.Deletes)
+	counter("evictions_total", "Entries dropped to respect capacity limits.", st.Evictions)
+	counter("expirations_total", "Entries removed after their TTL.", st.Expirations)
+
+	fmt.Fprintf(w, "# HELP %s_size Live entries in the store.\n# TYPE %s_size gauge\n%s_size %d\n", ns, ns, ns, st.Size)
+
+	if st.Latency == nil {
+		return
+	}
+	name := ns + "_op_duration_seconds"
+	fmt.Fprintf(w, "# HELP %s Latency of store operations.\n# TYPE %s histogram\n", name, name)
+	for _, op := range storeOpNames {
+		ls := st.Latency[op]
+		for i, bound := range ls.Bounds {
+			fmt.Fprintf(w, "%s_bucket{op=%q,le=%q} %d\n", name, op, formatSeconds(bound), ls.Buckets[i])
+		}
+		fmt.Fprintf(w, "%s_bucket{op=%q,le=\"+Inf\"} %d\n", name, op, ls.Count)
+		fmt.Fprintf(w, "%s_sum{op=%q} %s\n", name, op, formatSeconds(ls.Sum))
+		fmt.Fprintf(w, "%s_count{op=%q} %d\n", name, op, ls.Count)
+	}
+}
+
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'g', -1, 64)
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatsCounters(t *testing.T) {
+	s := newSingleton(WithMaxEntries(2))
+	s.Set("a", "1")
+	s.Set("a", "2")
+	s.Get("a")
+	s.Get("nada")
+	s.Get("nada")
+	s.Delete("a")
+	s.Delete("a") // Já não existe: não conta
+	s.SetWithTTL("b", "1", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	s.Get("b") // Remove a chave expirada
+	s.Set("c", "1")
+	s.Set("d", "1")
+	s.Set("e", "1") // Passa do limite: c sai
+
+	st := s.Stats()
+	want := Stats{Hits: 1, Misses: 3, Sets: 6, Deletes: 1, Evictions: 1, Expirations: 1, Size: 2}
+	if st.Latency != nil {
+		t.Errorf("latency tracked without WithLatencyTracking: %v", st.Latency)
+	}
+	if st.Hits != want.Hits || st.Misses != want.Misses || st.Sets != want.Sets || st.Deletes != want.Deletes ||
+		st.Evictions != want.Evictions || st.Expirations != want.Expirations || st.Size != want.Size {
+		t.Errorf("Stats = %+v\nwant    %+v", st, want)
+	}
+	if r := st.HitRatio(); r != 0.25 {
+		t.Errorf("HitRatio = %v, want 0.25", r)
+	}
+	if r := (Stats{}).HitRatio(); r != 0 {
+		t.Errorf("HitRatio before any read = %v", r)
+	}
+}
+
+func TestStatsLatency(t *testing.T) {
+	s := newSingleton(WithLatencyTracking())
+	s.Set("a", "1")
+	s.Get("a")
+	s.Get("a")
+	s.Txn(func(tx *Tx) error { return nil })
+
+	st := s.Stats()
+	for op, n := range map[string]uint64{"get": 2, "set": 1, "delete": 0, "txn": 1} {
+		ls := st.Latency[op]
+		if ls.Count != n {
+			t.Errorf("%s: count %d, want %d", op, ls.Count, n)
+		}
+		if len(ls.Buckets) != len(latencyBuckets) || (n > 0 && ls.Buckets[len(ls.Buckets)-1] > n) {
+			t.Errorf("%s: buckets %v", op, ls.Buckets)
+		}
+	}
+
+	// Desligar para de medir e some das Stats
+	s.SetLatencyTracking(false)
+	s.Get("a")
+	if st := s.Stats(); st.Latency != nil {
+		t.Errorf("latency after SetLatencyTracking(false): %v", st.Latency)
+	}
+	s.SetLatencyTracking(true)
+	if n := s.Stats().Latency["get"].Count; n != 2 {
+		t.Errorf("get count = %d, want the untimed Get left out", n)
+	}
+}
+
+func TestShardedStats(t *testing.T) {
+	s, _ := NewShardedStore(4, StoreConfig[string, string]{TrackLatency: true})
+	for _, key := range []string{"a", "b", "c", "d", "e", "f"} {
+		s.Set(key, "1")
+		s.Get(key)
+	}
+	s.Get("nada")
+	st := s.Stats()
+	if st.Sets != 6 || st.Hits != 6 || st.Misses != 1 || st.Size != 6 {
+		t.Errorf("Stats = %+v", st)
+	}
+	if n := st.Latency["get"].Count; n != 7 {
+		t.Errorf("get count = %d, want 7 over all shards", n)
+	}
+}
+
+func TestWritePrometheus(t *testing.T) {
+	st := Stats{Hits: 3, Misses: 1, Sets: 2, Size: 2}
+	var b strings.Builder
+	writePrometheus(&b, "kv", st)
+	want := `# HELP kv_hits_total Reads that found a live key.
+# TYPE kv_hits_total counter
+kv_hits_total 3
+# HELP kv_misses_total Reads that found no live key.
+# TYPE kv_misses_total counter
+kv_misses_total 1
+# HELP kv_sets_total Entries written.
+# TYPE kv_sets_total counter
+kv_sets_total 2
+# HELP kv_deletes_total Entries removed explicitly.
+# TYPE kv_deletes_total counter
+kv_deletes_total 0
+# HELP kv_evictions_total Entries dropped to respect capacity limits.
+# TYPE kv_evictions_total counter
+kv_evictions_total 0
+# HELP kv_expirations_total Entries removed after their TTL.
+# TYPE kv_expirations_total counter
+kv_expirations_total 0
+# HELP kv_size Live entries in the store.
+# TYPE kv_size gauge
+kv_size 2
+`
+	if b.String() != want {
+		t.Errorf("exposition without latency:\n%s\nwant:\n%s", b.String(), want)
+	}
+
+	// Com latência: um histograma por operação, buckets cumulativos
+	st.Latency = make(map[string]LatencyStats)
+	for _, op := range storeOpNames {
+		st.Latency[op] = LatencyStats{Bounds: latencyBuckets[:], Buckets: make([]uint64, len(latencyBuckets))}
+	}
+	get := st.Latency["get"]
+	for i := range get.Buckets {
+		get.Buckets[i] = 1
+	}
+	get.Buckets[len(get.Buckets)-1] = 2
+	get.Count, get.Sum = 3, 1500*time.Millisecond
+	st.Latency["get"] = get
+
+	b.Reset()
+	writePrometheus(&b, "kv", st)
+	out := b.String()
+	for _, line := range []string{
+		"# TYPE kv_op_duration_seconds histogram\n",
+		"kv_op_duration_seconds_bucket{op=\"get\",le=\"1e-06\"} 1\n",
+		"kv_op_duration_seconds_bucket{op=\"get\",le=\"1\"} 2\n",
+		"kv_op_duration_seconds_bucket{op=\"get\",le=\"+Inf\"} 3\n",
+		"kv_op_duration_seconds_sum{op=\"get\"} 1.5\n",
+		"kv_op_duration_seconds_count{op=\"get\"} 3\n",
+		"kv_op_duration_seconds_count{op=\"txn\"} 0\n",
+	} {
+		if !strings.Contains(out, line) {
+			t.Errorf("exposition lacks %q", line)
+		}
+	}
+	if n := strings.Count(out, "_bucket{op=\"set\""); n != len(latencyBuckets)+1 {
+		t.Errorf("set has %d bucket lines, want %d", n, len(latencyBuckets)+1)
+	}
+
+	rec := httptest.NewRecorder()
+	newMetricsHandler("kv", func() Stats { return st }).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if rec.Body.String() != out {
+		t.Error("handler output differs from writePrometheus")
+	}
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// namespaceSep separates the namespace name from the key in the shared
+// store: key "id" of namespace "sessions" is stored as "sessions:id".
+const namespaceSep = ":"
+
+var (
+	ErrQuotaExceeded    = errors.New("namespace: quota exceeded")
+	ErrInvalidNamespace = errors.New("namespace: invalid name")
+)
+
+// NamespaceOption configures a Namespace.
+type NamespaceOption func(*namespaceConfig)
+
+type namespaceConfig struct {
+	maxEntries int
+	maxBytes   int
+	defaultTTL time.Duration
+}
+
+// WithNamespaceMaxEntries bounds the number of keys in the namespace.
+func WithNamespaceMaxEntries(n int) NamespaceOption {
+	return func(c *namespaceConfig) { c.maxEntries = n }
+}
+
+// WithNamespaceMaxBytes bounds the sum of len(key)+len(value) over the
+// namespace, with keys measured without the namespace prefix.
+func WithNamespaceMaxBytes(n int) NamespaceOption {
+	return func(c *namespaceConfig) { c.maxBytes = n }
+}
+
+// WithDefaultTTL sets the TTL used by Namespace.Set.
+func WithDefaultTTL(ttl time.Duration) NamespaceOption {
+	return func(c *namespaceConfig) { c.defaultTTL = ttl }
+}
+
+// Namespace is a view over the keys of the singleton starting with
+// name + ":". Keys are passed and returned without the prefix. Unlike the
+// capacity limits of the store, which evict, a namespace quota rejects the
+// write that would exceed it.
+type Namespace struct {
+	s      *singleton
+	name   string
+	prefix string
+
+	// Protegidos pelo mutex do store
+	cfg     namespaceConfig
+	entries int
+	bytes   int
+
+	hits, misses, sets, deletes      atomic.Uint64
+	evictions, expirations, rejected atomic.Uint64
+}
+
+// NamespaceStats is a point-in-time copy of the counters of a namespace.
+// Entries and Bytes include expired keys not yet removed.
+type NamespaceStats struct {
+	Name        string        `json:"name"`
+	Entries     int           `json:"entries"`
+	Bytes       int           `json:"bytes"`
+	MaxEntries  int           `json:"max_entries"`
+	MaxBytes    int           `json:"max_bytes"`
+	DefaultTTL  time.Duration `json:"default_ttl"`
+	Hits        uint64        `json:"hits"`
+	Misses      uint64        `json:"misses"`
+	Sets        uint64        `json:"sets"`
+	Deletes     uint64        `json:"deletes"`
+	Evictions   uint64        `json:"evictions"`
+	Expirations uint64        `json:"expirations"`
+	Rejected    uint64        `json:"rejected"`
+}
+
+// namespaceTable routes the store accounting hooks to the namespace that
+// owns each key. It is only accessed under the store mutex.
+type namespaceTable map[string]*Namespace
+
+func (t namespaceTable) lookup(key string) *Namespace {
+	name, _, ok := strings.Cut(key, namespaceSep)
+	if !ok {
+		return nil
+	}
+	return t[name]
+}
+
+func (t namespaceTable) stored(key, old string, hadOld bool, value string) {
+	ns := t.lookup(key)
+	if ns == nil {
+		return
+	}
+	if hadOld {
+		ns.bytes -= ns.sizeOf(key, old)
+	} else {
+		ns.entries++
+	}
+	ns.bytes += ns.sizeOf(key, value)
+	ns.sets.Add(1)
+}
+
+func (t namespaceTable) removed(key, value string, kind EventKind) {
+	ns := t.lookup(key)
+	if ns == nil {
+		return
+	}
+	ns.entries--
+	ns.bytes -= ns.sizeOf(key, value)
+	switch kind {
+	case EventDelete:
+		ns.deletes.Add(1)
+	case EventExpire:
+		ns.expirations.Add(1)
+	case EventEvict:
+		ns.evictions.Add(1)
+	}
+}
+
+// Namespace returns the view named name, creating it on first use; keys
+// already stored under its prefix are adopted. opts, if any, replace the
+// settings of the view. An empty name or one containing ":" returns
+// ErrInvalidNamespace.
+func (s *singleton) Namespace(name string, opts ...NamespaceOption) (*Namespace, error) {
+	if name == "" || strings.Contains(name, namespaceSep) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidNamespace, name)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	ns, ok := s.namespaces[name]
+	if !ok {
+		ns = &Namespace{s: s, name: name, prefix: name + namespaceSep}
+		for key, value := range s.data {
+			if strings.HasPrefix(key, ns.prefix) {
+				ns.entries++
+				ns.bytes += ns.sizeOf(key, value)
+			}
+		}
+		if s.namespaces == nil {
+			s.namespaces = make(namespaceTable)
+			s.accounting = s.namespaces
+		}
+		s.namespaces[name] = ns
+	}
+	for _, opt := range opts {
+		opt(&ns.cfg)
+	}
+	return ns, nil
+}
+
+func (ns *Namespace) Name() string { return ns.name }
+
+// sizeOf measures a stored entry for the byte quota.
+func (ns *Namespace) sizeOf(key, value string) int {
+	return len(key) - len(ns.prefix) + len(value)
+}
+
+// Set stores key with the namespace default TTL.
+func (ns *Namespace) Set(key, value string) error {
+	return ns.set(key, value, func() time.Duration { return ns.cfg.defaultTTL })
+}
+
+// SetWithTTL stores key with an explicit TTL; ttl <= 0 means no expiry.
+func (ns *Namespace) SetWithTTL(key, value string, ttl time.Duration) error {
+	return ns.set(key, value, func() time.Duration { return ttl })
+}
+
+// set checks the quota and writes in one transaction, so concurrent
+// writers cannot both squeeze into the last free slot.
+func (ns *Namespace) set(key, value string, ttl func() time.Duration) error {
+	return ns.s.Txn(func(tx *Tx) error {
+		stored := ns.prefix + key
+		entries, bytes := ns.entries+1, ns.bytes+ns.sizeOf(stored, value)
+		if old, ok := tx.store.data[stored]; ok {
+			entries--
+			bytes -= ns.sizeOf(stored, old)
+		}
+		if ns.overQuota(entries, bytes) {
+			// Chaves expiradas ainda não varridas não contam contra a cota
+			expiredEntries, expiredBytes := ns.expiredLocked(tx.now, stored)
+			if ns.overQuota(entries-expiredEntries, bytes-expiredBytes) {
+				ns.rejected.Add(1)
+				return fmt.Errorf("%w: namespace %q", ErrQuotaExceeded, ns.name)
+			}
+		}
+		tx.SetWithTTL(stored, value, ttl())
+		return nil
+	})
+}
+
+func (ns *Namespace) overQuota(entries, bytes int) bool {
+	return (ns.cfg.maxEntries > 0 && entries > ns.cfg.maxEntries) ||
+		(ns.cfg.maxBytes > 0 && bytes > ns.cfg.maxBytes)
+}
+
+// expiredLocked measures the expired keys of the namespace other than
+// except. Caller holds the store mutex.
+func (ns *Namespace) expiredLocked(now time.Time, except string) (entries, bytes int) {
+	for key, deadline := range ns.s.expires {
+		if key != except && strings.HasPrefix(key, ns.prefix) && !now.Before(deadline) {
+			entries++
+			bytes += ns.sizeOf(key, ns.s.data[key])
+		}
+	}
+	return entries, bytes
+}
+
+func (ns *Namespace) Get(key string) (string, bool) {
+	value, ok := ns.s.Get(ns.prefix + key)
+	if ok {
+		ns.hits.Add(1)
+	} else {
+		ns.misses.Add(1)
+	}
+	return value, ok
+}
+
+func (ns *Namespace) Delete(key string) {
+	ns.s.Delete(ns.prefix + key)
+}
+
+// Size returns the number of live keys in the namespace.
+func (ns *Namespace) Size() int {
+	ns.s.mutex.RLock()
+	defer ns.s.mutex.RUnlock()
+
+	expired, _ := ns.expiredLocked(time.Now(), "")
+	return ns.entries - expired
+}
+
+// Keys returns the live keys of the namespace starting with prefix,
+// sorted and without the namespace prefix.
+func (ns *Namespace) Keys(prefix string) []string {
+	keys := ns.s.Keys(ns.prefix + prefix)
+	for i, key := range keys {
+		keys[i] = key[len(ns.prefix):]
+	}
+	return keys
+}
+
+// Drop deletes every key of the namespace in one transaction: readers
+// see either all of them or none, and watchers get the deletions as one
+// batch. It returns how many keys were removed. The namespace itself and
+// its settings remain.
+func (ns *Namespace) Drop() int {
+	var n int
+	ns.s.Txn(func(tx *Tx) error {
+		for key := range tx.store.data {
+			if strings.HasPrefix(key, ns.prefix) {
+				tx.Delete(key)
+				n++
+			}
+		}
+		return nil
+	})
+	return n
+}
+
+func (ns *Namespace) Stats() NamespaceStats {
+	ns.s.mutex.RLock()
+	st := NamespaceStats{
+		Name:       ns.name,
+		Entries:    ns.entries,
+		Bytes:      ns.bytes,
+		MaxEntries: ns.cfg.maxEntries,
+		MaxBytes:   ns.cfg.maxBytes,
+		DefaultTTL: ns.cfg.defaultTTL,
+	}
+	ns.s.mutex.RUnlock()
+
+	st.Hits = ns.hits.Load()
+	st.Misses = ns.misses.Load()
+	st.Sets = ns.sets.Load()
+	st.Deletes = ns.deletes.Load()
+	st.Evictions = ns.evictions.Load()
+	st.Expirations = ns.expirations.Load()
+	st.Rejected = ns.rejected.Load()
+	return st
+}
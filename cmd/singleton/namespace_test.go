@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestNamespaceInvalidName(t *testing.T) {
+	s := newSingleton()
+	for _, name := range []string{"", "a:b", ":"} {
+		if ns, err := s.Namespace(name); !errors.Is(err, ErrInvalidNamespace) || ns != nil {
+			t.Errorf("Namespace(%q) = %v, %v; want ErrInvalidNamespace", name, ns, err)
+		}
+	}
+	a, _ := s.Namespace("a")
+	if again, err := s.Namespace("a"); err != nil || again != a {
+		t.Errorf("second Namespace(a) = %p, %v; want the same view", again, err)
+	}
+}
+
+func TestNamespaceQuota(t *testing.T) {
+	s := newSingleton()
+	s.Set("usuarios:0", "adotada") // Já estava no store: conta na cota
+	ns, _ := s.Namespace("usuarios", WithNamespaceMaxEntries(2), WithNamespaceMaxBytes(12))
+
+	if err := ns.Set("1", "ana"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ns.Set("2", "bia"); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("third key: err = %v, want ErrQuotaExceeded", err)
+	}
+	// Sobrescrever não ocupa vaga nova
+	if err := ns.Set("1", "eva"); err != nil {
+		t.Errorf("overwrite at the entry quota: %v", err)
+	}
+	if err := ns.Set("1", "maria"); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("overwrite over the byte quota: err = %v", err)
+	}
+	if v, _ := ns.Get("1"); v != "eva" {
+		t.Errorf("rejected write changed the value to %q", v)
+	}
+
+	// Uma chave expirada, mesmo não varrida, libera a vaga
+	ns.Delete("0")
+	ns.SetWithTTL("0", "x", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if err := ns.Set("2", "bia"); err != nil {
+		t.Errorf("expired key still counted: %v", err)
+	}
+	if st := ns.Stats(); st.Rejected != 2 {
+		t.Errorf("Rejected = %d, want 2", st.Rejected)
+	}
+}
+
+func TestNamespaceDrop(t *testing.T) {
+	s := newSingleton()
+	a, _ := s.Namespace("a")
+	b, _ := s.Namespace("b")
+	a.Set("1", "x")
+	a.Set("2", "y")
+	b.Set("1", "z")
+	s.Set("a", "sem namespace")
+
+	if n := a.Drop(); n != 2 {
+		t.Errorf("Drop = %d, want 2", n)
+	}
+	if got := s.Keys(""); !slices.Equal(got, []string{"a", "b:1"}) {
+		t.Errorf("keys after Drop = %q", got)
+	}
+	if a.Size() != 0 || a.Stats().Bytes != 0 {
+		t.Errorf("dropped namespace has %d keys, %d bytes", a.Size(), a.Stats().Bytes)
+	}
+	// O namespace continua utilizável
+	if err := a.Set("3", "w"); err != nil || a.Size() != 1 {
+		t.Errorf("Set after Drop = %v, size %d", err, a.Size())
+	}
+}
+
+func TestNamespaceStats(t *testing.T) {
+	s := newSingleton(WithMaxEntries(3))
+	ns, _ := s.Namespace("ns", WithDefaultTTL(time.Hour))
+	other, _ := s.Namespace("outro")
+
+	ns.Set("a", "1")
+	ns.Set("a", "22")
+	ns.Get("a")
+	ns.Get("nada")
+	ns.SetWithTTL("b", "1", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	ns.Get("b") // Remove a chave expirada
+	ns.Set("c", "1")
+	ns.Delete("c")
+	other.Set("x", "1")
+
+	// O store só cabe 3 chaves: as próximas tiram "ns:a" e "outro:x"
+	s.Set("k1", "1")
+	s.Set("k2", "1")
+	s.Set("k3", "1")
+
+	want := NamespaceStats{
+		Name: "ns", DefaultTTL: time.Hour,
+		Hits: 1, Misses: 2, Sets: 4, Deletes: 1, Evictions: 1, Expirations: 1,
+	}
+	if got := ns.Stats(); got != want {
+		t.Errorf("Stats = %+v\nwant    %+v", got, want)
+	}
+	if st := other.Stats(); st.Sets != 1 || st.Evictions != 1 || st.Entries != 0 {
+		t.Errorf("other namespace stats = %+v", st)
+	}
+}
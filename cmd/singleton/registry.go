@@ -0,0 +1,276 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	ErrNotRegistered     = errors.New("registry: not registered")
+	ErrAlreadyRegistered = errors.New("registry: already registered")
+	ErrTypeMismatch      = errors.New("registry: type mismatch")
+	ErrDependencyCycle   = errors.New("registry: dependency cycle")
+)
+
+// Registry holds named lazy providers. Unlike the package-level instance
+// behind GetInstance_example_N, each name owns its own value, so several
+// singletons can live side by side in one process.
+type Registry struct {
+	mu      sync.RWMutex
+	entries map[string]*registration
+
+	// Protege owner e done dos registros e o estado das Resolutions: é o
+	// grafo de quem espera quem
+	buildingMu sync.Mutex
+}
+
+type registration struct {
+	name  string
+	typ   reflect.Type
+	deps  []string
+	build func(*Resolution) (any, error)
+
+	owner *Resolution   // Resolução construindo o nome agora, ou nil
+	done  chan struct{} // Fechado quando a construção de owner termina
+
+	built atomic.Bool
+	value any // Escrito antes de built, lido depois
+}
+
+// Resolution is one chain of builds: a GetFrom call and the providers it
+// runs. A provider receives it and reads the names it needs through
+// GetWithin, so the registry knows which build waits for which and can
+// report a cycle, even one spanning goroutines, instead of blocking. It is
+// only valid while the provider runs.
+type Resolution struct {
+	r        *Registry
+	building []*registration // Construções em andamento, da mais externa à atual
+	waiting  *registration   // Construção de outra resolução que esta espera
+}
+
+func NewRegistry() *Registry {
+	return &Registry{entries: make(map[string]*registration)}
+}
+
+var defaultRegistry = NewRegistry()
+
+// Register adds a provider to the default registry. See RegisterIn.
+func Register[T any](name string, provider func(*Resolution) (T, error), dependsOn ...string) error {
+	return RegisterIn(defaultRegistry, name, provider, dependsOn...)
+}
+
+// Get returns the value of name from the default registry. See GetFrom.
+func Get[T any](name string) (T, error) {
+	return GetFrom[T](defaultRegistry, name)
+}
+
+// RegisterIn adds a lazy provider for name. dependsOn lists names that
+// are built before the provider runs; cycles among them are reported
+// before anything is built. A provider may also read undeclared names
+// with GetWithin: a cycle through those is reported as ErrDependencyCycle
+// when it closes. Calling GetFrom from a provider instead starts a new
+// Resolution, which the registry cannot connect to this build.
+func RegisterIn[T any](r *Registry, name string, provider func(*Resolution) (T, error), dependsOn ...string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.entries[name]; ok {
+		return fmt.Errorf("%w: %q", ErrAlreadyRegistered, name)
+	}
+	r.entries[name] = &registration{
+		name: name,
+		typ:  reflect.TypeFor[T](),
+		deps: append([]string(nil), dependsOn...),
+		build: func(res *Resolution) (any, error) {
+			return provider(res)
+		},
+	}
+	return nil
+}
+
+// GetFrom builds name on first use, after its dependencies, and returns
+// the same value on every later call. A failed build is not cached: the
+// next call tries again.
+func GetFrom[T any](r *Registry, name string) (T, error) {
+	return resolve[T](&Resolution{r: r}, name)
+}
+
+// GetWithin is GetFrom for a provider: it reads name from the registry
+// res belongs to as part of the build in progress.
+func GetWithin[T any](res *Resolution, name string) (T, error) {
+	return resolve[T](res, name)
+}
+
+func resolve[T any](res *Resolution, name string) (T, error) {
+	var zero T
+
+	r := res.r
+	order, err := r.buildOrder(name)
+	if err != nil {
+		return zero, err
+	}
+	target := order[len(order)-1]
+	if want := reflect.TypeFor[T](); target.typ != want {
+		return zero, fmt.Errorf("%w: %q is %v, not %v", ErrTypeMismatch, name, target.typ, want)
+	}
+
+	var value any
+	for _, reg := range order {
+		if value, err = r.get(res, reg); err != nil {
+			if reg != target {
+				return zero, fmt.Errorf("registry: building %q for %q: %w", reg.name, name, err)
+			}
+			return zero, fmt.Errorf("registry: building %q: %w", name, err)
+		}
+	}
+	v, _ := value.(T) // nil de interface não passa no type assertion
+	return v, nil
+}
+
+// buildOrder returns name and its transitive dependencies with every
+// dependency before its dependents, or an error naming the first cycle
+// or missing provider found.
+func (r *Registry) buildOrder(name string) ([]*registration, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return dependencyOrder([]string{name}, func(n string) (*registration, []string, bool) {
+		reg, ok := r.entries[n]
+		if !ok {
+			return nil, nil, false
+		}
+		return reg, reg.deps, true
+	}, ErrNotRegistered, ErrDependencyCycle)
+}
+
+// dependencyOrder walks the graph reachable from roots depth-first and
+// returns its nodes with every dependency before its dependents. lookup
+// resolves a name to its node and dependencies; unknown names and cycles
+// are reported wrapping errMissing and errCycle.
+func dependencyOrder[T any](roots []string, lookup func(string) (T, []string, bool), errMissing, errCycle error) ([]T, error) {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int)
+	var order []T
+	var path []string
+
+	var visit func(string) error
+	visit = func(n string) error {
+		switch state[n] {
+		case done:
+			return nil
+		case visiting:
+			start := 0
+			for path[start] != n {
+				start++
+			}
+			cycle := append(append([]string(nil), path[start:]...), n)
+			return fmt.Errorf("%w: %s", errCycle, strings.Join(cycle, " -> "))
+		}
+		node, deps, ok := lookup(n)
+		if !ok {
+			if len(path) == 0 {
+				return fmt.Errorf("%w: %q", errMissing, n)
+			}
+			return fmt.Errorf("%w: %q (required by %q)", errMissing, n, path[len(path)-1])
+		}
+
+		state[n] = visiting
+		path = append(path, n)
+		for _, dep := range deps {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[n] = done
+		order = append(order, node)
+		return nil
+	}
+
+	for _, root := range roots {
+		if err := visit(root); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// get builds reg once for res. When another resolution is building it,
+// res waits for that build, unless the waits would close a cycle back to
+// res: then nobody could finish, and the cycle is returned as an error.
+func (r *Registry) get(res *Resolution, reg *registration) (any, error) {
+	for {
+		if reg.built.Load() {
+			return reg.value, nil
+		}
+
+		r.buildingMu.Lock()
+		res.waiting = nil
+		if reg.built.Load() {
+			r.buildingMu.Unlock()
+			return reg.value, nil
+		}
+		if reg.owner == nil {
+			reg.owner = res
+			reg.done = make(chan struct{})
+			res.building = append(res.building, reg)
+			r.buildingMu.Unlock()
+			return r.build(res, reg)
+		}
+		if cycle := waitCycle(res, reg); cycle != nil {
+			r.buildingMu.Unlock()
+			return nil, fmt.Errorf("%w: %s", ErrDependencyCycle, strings.Join(cycle, " -> "))
+		}
+		res.waiting = reg
+		done := reg.done
+		r.buildingMu.Unlock()
+
+		// A construção pode falhar; nesse caso a próxima volta tenta de novo
+		<-done
+	}
+}
+
+// build runs the provider of reg, which res owns, and hands reg back to
+// the waiters even if the provider panics.
+func (r *Registry) build(res *Resolution, reg *registration) (any, error) {
+	defer func() {
+		r.buildingMu.Lock()
+		defer r.buildingMu.Unlock()
+		res.building = res.building[:len(res.building)-1]
+		reg.owner = nil
+		close(reg.done)
+	}()
+
+	value, err := reg.build(res)
+	if err == nil {
+		reg.value = value
+		reg.built.Store(true)
+	}
+	return value, err
+}
+
+// waitCycle returns the names along which waiting for reg would come back
+// to res, or nil if res can wait. It follows reg to the resolution
+// building it, that resolution to the build it waits for, and so on; the
+// graph has no other cycles, since every wait is checked before it starts.
+func waitCycle(res *Resolution, reg *registration) []string {
+	var names []string
+	for cur := reg; cur != nil && cur.owner != nil; cur = cur.owner.waiting {
+		owner := cur.owner
+		for _, b := range owner.building[slices.Index(owner.building, cur):] {
+			names = append(names, b.name)
+		}
+		if owner == res {
+			return append(names, reg.name)
+		}
+	}
+	return nil
+}
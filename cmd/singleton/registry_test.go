@@ -0,0 +1,174 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// getWithin fails the test instead of hanging when GetFrom deadlocks.
+func getWithin[T any](t *testing.T, r *Registry, name string) (T, error) {
+	t.Helper()
+	type result struct {
+		v   T
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		v, err := GetFrom[T](r, name)
+		done <- result{v, err}
+	}()
+	select {
+	case res := <-done:
+		return res.v, res.err
+	case <-time.After(5 * time.Second):
+		t.Fatalf("GetFrom(%q) blocked", name)
+		panic("unreachable")
+	}
+}
+
+func TestRegistryCycles(t *testing.T) {
+	tests := []struct {
+		name     string
+		register func(r *Registry)
+	}{
+		{"declared", func(r *Registry) {
+			RegisterIn(r, "a", func(*Resolution) (int, error) { return 1, nil }, "b")
+			RegisterIn(r, "b", func(*Resolution) (int, error) { return 2, nil }, "a")
+		}},
+		{"self", func(r *Registry) {
+			RegisterIn(r, "a", func(res *Resolution) (int, error) { return GetWithin[int](res, "a") })
+		}},
+		{"undeclared", func(r *Registry) {
+			RegisterIn(r, "a", func(res *Resolution) (int, error) { return GetWithin[int](res, "b") })
+			RegisterIn(r, "b", func(res *Resolution) (int, error) { return GetWithin[int](res, "c") })
+			RegisterIn(r, "c", func(res *Resolution) (int, error) { return GetWithin[int](res, "a") })
+		}},
+		{"undeclared through a declared one", func(r *Registry) {
+			RegisterIn(r, "a", func(*Resolution) (int, error) { return 1, nil }, "b")
+			RegisterIn(r, "b", func(res *Resolution) (int, error) { return GetWithin[int](res, "a") })
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry()
+			tt.register(r)
+			if _, err := getWithin[int](t, r, "a"); !errors.Is(err, ErrDependencyCycle) {
+				t.Fatalf("err = %v, want ErrDependencyCycle", err)
+			}
+			// O erro não fica em cache nem deixa o registro travado
+			if _, err := getWithin[int](t, r, "a"); !errors.Is(err, ErrDependencyCycle) {
+				t.Fatalf("second call: err = %v, want ErrDependencyCycle", err)
+			}
+		})
+	}
+}
+
+func TestRegistryConcurrentBuild(t *testing.T) {
+	r := NewRegistry()
+	var builds atomic.Int32
+	RegisterIn(r, "config", func(*Resolution) (string, error) {
+		time.Sleep(10 * time.Millisecond)
+		return "cfg", nil
+	})
+	RegisterIn(r, "service", func(res *Resolution) (*int, error) {
+		builds.Add(1)
+		// Dependência não declarada pedida de dentro do provider
+		if _, err := GetWithin[string](res, "config"); err != nil {
+			return nil, err
+		}
+		n := 42
+		return &n, nil
+	})
+
+	var wg sync.WaitGroup
+	results := make([]*int, 16)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := GetFrom[*int](r, "service")
+			if err != nil {
+				t.Error(err)
+			}
+			results[i] = v
+		}()
+	}
+	wg.Wait()
+	if n := builds.Load(); n != 1 {
+		t.Errorf("built %d times, want 1", n)
+	}
+	for _, v := range results {
+		if v != results[0] {
+			t.Fatal("goroutines got different values")
+		}
+	}
+}
+
+func TestRegistryCycleAcrossGoroutines(t *testing.T) {
+	r := NewRegistry()
+	// Cada provider só pede o outro depois que os dois já começaram
+	var started atomic.Int32
+	both := make(chan struct{})
+	arrive := func() {
+		if started.Add(1) == 2 {
+			close(both)
+		}
+		<-both
+	}
+	RegisterIn(r, "a", func(res *Resolution) (int, error) {
+		arrive()
+		return GetWithin[int](res, "b")
+	})
+	RegisterIn(r, "b", func(res *Resolution) (int, error) {
+		arrive()
+		return GetWithin[int](res, "a")
+	})
+
+	errs := make(chan error, 2)
+	for _, name := range []string{"a", "b"} {
+		go func() {
+			_, err := GetFrom[int](r, name)
+			errs <- err
+		}()
+	}
+	for range 2 {
+		select {
+		case err := <-errs:
+			if !errors.Is(err, ErrDependencyCycle) {
+				t.Errorf("err = %v, want ErrDependencyCycle", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("GetFrom deadlocked")
+		}
+	}
+}
+
+func TestRegistryWaitsForOtherBuild(t *testing.T) {
+	r := NewRegistry()
+	release := make(chan struct{})
+	RegisterIn(r, "slow", func(*Resolution) (int, error) {
+		<-release
+		return 1, nil
+	})
+	// Dois nomes que esperam o mesmo build não formam um ciclo
+	RegisterIn(r, "x", func(res *Resolution) (int, error) { return GetWithin[int](res, "slow") })
+	RegisterIn(r, "y", func(res *Resolution) (int, error) { return GetWithin[int](res, "slow") })
+
+	errs := make(chan error, 3)
+	for _, name := range []string{"slow", "x", "y"} {
+		go func() {
+			_, err := GetFrom[int](r, name)
+			errs <- err
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	for range 3 {
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	}
+}
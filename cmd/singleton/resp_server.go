@@ -0,0 +1,564 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limites do parser para um cliente não conseguir esgotar a memória
+const (
+	respMaxArgs     = 1024
+	respMaxBulk     = 8 << 20
+	respReadChunk   = 64 << 10
+	respMaxInline   = 64 << 10
+	respIdleTimeout = 5 * time.Minute
+)
+
+var errRESPProtocol = errors.New("protocol error")
+
+// respServer speaks the subset of RESP2 needed by redis-cli and common
+// client libraries: GET, SET (EX/PX), DEL, EXISTS, DBSIZE, KEYS, PING,
+// ECHO, EXPIRE, TTL and QUIT. Each connection gets its own goroutine and
+// replies are flushed only when no pipelined command is left to read.
+type respServer struct {
+	store *singleton
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	closing  bool
+	wg       sync.WaitGroup
+}
+
+func newRESPServer(s *singleton) *respServer {
+	return &respServer{store: s, conns: make(map[net.Conn]struct{})}
+}
+
+// Serve accepts connections on l until Shutdown is called. If Shutdown
+// already ran, Serve closes l and returns net.ErrClosed right away.
+func (srv *respServer) Serve(l net.Listener) error {
+	srv.mu.Lock()
+	if srv.closing {
+		srv.mu.Unlock()
+		l.Close()
+		return net.ErrClosed
+	}
+	srv.listener = l
+	srv.mu.Unlock()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			srv.mu.Lock()
+			closing := srv.closing
+			srv.mu.Unlock()
+			if closing {
+				return nil
+			}
+			return err
+		}
+
+		srv.mu.Lock()
+		if srv.closing {
+			srv.mu.Unlock()
+			conn.Close()
+			return nil
+		}
+		srv.conns[conn] = struct{}{}
+		srv.wg.Add(1)
+		srv.mu.Unlock()
+
+		go srv.handle(conn)
+	}
+}
+
+// Shutdown stops accepting connections and asks every connection to stop
+// after its current command. Connections still busy when ctx ends are
+// closed forcefully.
+func (srv *respServer) Shutdown(ctx context.Context) error {
+	srv.mu.Lock()
+	srv.closing = true
+	if srv.listener != nil {
+		srv.listener.Close()
+	}
+	for conn := range srv.conns {
+		// Interrompe o read bloqueado; o comando em andamento termina
+		conn.SetReadDeadline(time.Now())
+	}
+	srv.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		srv.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		srv.mu.Lock()
+		for conn := range srv.conns {
+			conn.Close()
+		}
+		srv.mu.Unlock()
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (srv *respServer) handle(conn net.Conn) {
+	defer func() {
+		srv.mu.Lock()
+		delete(srv.conns, conn)
+		srv.mu.Unlock()
+		conn.Close()
+		srv.wg.Done()
+	}()
+
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	for {
+		// Checa e renova o deadline sob o lock para não sobrescrever o
+		// deadline imediato definido por Shutdown
+		srv.mu.Lock()
+		closing := srv.closing
+		if !closing {
+			conn.SetReadDeadline(time.Now().Add(respIdleTimeout))
+		}
+		srv.mu.Unlock()
+		if closing && r.Buffered() == 0 {
+			w.Flush()
+			return
+		}
+
+		args, err := readRESPCommand(r)
+		if err != nil {
+			if errors.Is(err, errRESPProtocol) {
+				writeRESPError(w, "ERR "+err.Error())
+			}
+			w.Flush()
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		quit := srv.execute(w, args)
+		if r.Buffered() == 0 || quit {
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+		if quit {
+			return
+		}
+	}
+}
+
+// execute runs one command and reports whether the connection should be
+// closed afterwards.
+func (srv *respServer) execute(w *bufio.Writer, args []string) (quit bool) {
+	s := srv.store
+	cmd := strings.ToUpper(args[0])
+	args = args[1:]
+
+	wrongArgs := func() {
+		writeRESPError(w, fmt.Sprintf("ERR wrong number of arguments for '%s' command", strings.ToLower(cmd)))
+	}
+
+	switch cmd {
+	case "PING":
+		switch len(args) {
+		case 0:
+			writeRESPSimple(w, "PONG")
+		case 1:
+			writeRESPBulk(w, args[0])
+		default:
+			wrongArgs()
+		}
+
+	case "ECHO":
+		if len(args) != 1 {
+			wrongArgs()
+			return false
+		}
+		writeRESPBulk(w, args[0])
+
+	case "QUIT":
+		writeRESPSimple(w, "OK")
+		return true
+
+	case "GET":
+		if len(args) != 1 {
+			wrongArgs()
+			return false
+		}
+		if value, ok := s.Get(args[0]); ok {
+			writeRESPBulk(w, value)
+		} else {
+			writeRESPNull(w)
+		}
+
+	case "SET":
+		if len(args) < 2 {
+			wrongArgs()
+			return false
+		}
+		ttl, err := parseSetOptions(args[2:])
+		if err != nil {
+			writeRESPError(w, err.Error())
+			return false
+		}
+		s.SetWithTTL(args[0], args[1], ttl)
+		writeRESPSimple(w, "OK")
+
+	case "DEL":
+		if len(args) == 0 {
+			wrongArgs()
+			return false
+		}
+		// Uma transação só: DEL de várias chaves é atômico como no Redis
+		removed := 0
+		s.Txn(func(tx *Tx) error {
+			for _, key := range args {
+				if _, ok := tx.Get(key); ok {
+					tx.Delete(key)
+					removed++
+				}
+			}
+			return nil
+		})
+		writeRESPInt(w, int64(removed))
+
+	case "EXISTS":
+		if len(args) == 0 {
+			wrongArgs()
+			return false
+		}
+		found := 0
+		for _, key := range args {
+			if _, ok := s.Get(key); ok {
+				found++
+			}
+		}
+		writeRESPInt(w, int64(found))
+
+	case "DBSIZE":
+		if len(args) != 0 {
+			wrongArgs()
+			return false
+		}
+		writeRESPInt(w, int64(s.Size()))
+
+	case "KEYS":
+		if len(args) != 1 {
+			wrongArgs()
+			return false
+		}
+		var keys []string
+		for key := range s.All() {
+			if globMatch(args[0], key) {
+				keys = append(keys, key)
+			}
+		}
+		fmt.Fprintf(w, "*%d\r\n", len(keys))
+		for _, key := range keys {
+			writeRESPBulk(w, key)
+		}
+
+	case "EXPIRE":
+		if len(args) != 2 {
+			wrongArgs()
+			return false
+		}
+		seconds, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			writeRESPError(w, "ERR value is not an integer or out of range")
+			return false
+		}
+		ttl, ok := respTTL(seconds, time.Second)
+		if !ok {
+			writeRESPError(w, "ERR invalid expire time in 'expire' command")
+			return false
+		}
+		if s.Expire(args[0], ttl) {
+			writeRESPInt(w, 1)
+		} else {
+			writeRESPInt(w, 0)
+		}
+
+	case "TTL":
+		if len(args) != 1 {
+			wrongArgs()
+			return false
+		}
+		ttl, ok := s.TTL(args[0])
+		switch {
+		case !ok:
+			writeRESPInt(w, -2)
+		case ttl == 0:
+			writeRESPInt(w, -1)
+		default:
+			// Arredonda para o mais próximo como o Redis: 1.4s -> 1, 1.5s -> 2
+			writeRESPInt(w, int64((ttl+time.Second/2)/time.Second))
+		}
+
+	default:
+		writeRESPError(w, fmt.Sprintf("ERR unknown command '%s'", strings.ToLower(cmd)))
+	}
+	return false
+}
+
+// parseSetOptions handles the EX seconds / PX milliseconds options of SET.
+func parseSetOptions(opts []string) (time.Duration, error) {
+	var ttl time.Duration
+	for i := 0; i < len(opts); i++ {
+		unit := time.Duration(0)
+		switch strings.ToUpper(opts[i]) {
+		case "EX":
+			unit = time.Second
+		case "PX":
+			unit = time.Millisecond
+		default:
+			return 0, errors.New("ERR syntax error")
+		}
+		if ttl != 0 || i+1 >= len(opts) {
+			return 0, errors.New("ERR syntax error")
+		}
+		i++
+		n, err := strconv.ParseInt(opts[i], 10, 64)
+		if err != nil {
+			return 0, errors.New("ERR value is not an integer or out of range")
+		}
+		d, ok := respTTL(n, unit)
+		if n <= 0 || !ok {
+			return 0, errors.New("ERR invalid expire time in 'set' command")
+		}
+		ttl = d
+	}
+	return ttl, nil
+}
+
+// respTTL converts n units to a Duration, reporting false when the
+// result does not fit in one (about 292 years).
+func respTTL(n int64, unit time.Duration) (time.Duration, bool) {
+	if n > math.MaxInt64/int64(unit) || n < math.MinInt64/int64(unit) {
+		return 0, false
+	}
+	return time.Duration(n) * unit, true
+}
+
+// readRESPCommand reads either a RESP array of bulk strings or an inline
+// command (words separated by spaces, as typed in telnet).
+func readRESPCommand(r *bufio.Reader) ([]string, error) {
+	line, err := readRESPLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, nil
+	}
+	if line[0] != '*' {
+		return strings.Fields(line), nil
+	}
+
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n > respMaxArgs {
+		return nil, fmt.Errorf("%w: invalid multibulk length", errRESPProtocol)
+	}
+	args := make([]string, 0, max(n, 0))
+	for i := 0; i < n; i++ {
+		head, err := readRESPLine(r)
+		if err != nil {
+			return nil, err
+		}
+		if len(head) == 0 || head[0] != '$' {
+			return nil, fmt.Errorf("%w: expected '$', got '%.1s'", errRESPProtocol, head)
+		}
+		size, err := strconv.Atoi(head[1:])
+		if err != nil || size < 0 || size > respMaxBulk {
+			return nil, fmt.Errorf("%w: invalid bulk length", errRESPProtocol)
+		}
+		// Lê aos poucos: a memória cresce com os bytes que chegam, não com
+		// o tamanho anunciado
+		var arg strings.Builder
+		arg.Grow(min(size, respReadChunk))
+		if _, err := io.CopyN(&arg, r, int64(size)); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		var crlf [2]byte
+		if _, err := io.ReadFull(r, crlf[:]); err != nil {
+			return nil, err
+		}
+		if crlf != [2]byte{'\r', '\n'} {
+			return nil, fmt.Errorf("%w: bulk string not terminated by CRLF", errRESPProtocol)
+		}
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
+
+func readRESPLine(r *bufio.Reader) (string, error) {
+	var line []byte
+	for {
+		chunk, isPrefix, err := r.ReadLine()
+		if err != nil {
+			return "", err
+		}
+		line = append(line, chunk...)
+		if len(line) > respMaxInline {
+			return "", fmt.Errorf("%w: line too long", errRESPProtocol)
+		}
+		if !isPrefix {
+			return string(line), nil
+		}
+	}
+}
+
+func writeRESPSimple(w *bufio.Writer, s string) { fmt.Fprintf(w, "+%s\r\n", s) }
+func writeRESPError(w *bufio.Writer, s string)  { fmt.Fprintf(w, "-%s\r\n", s) }
+func writeRESPInt(w *bufio.Writer, n int64)     { fmt.Fprintf(w, ":%d\r\n", n) }
+func writeRESPNull(w *bufio.Writer)             { w.WriteString("$-1\r\n") }
+func writeRESPBulk(w *bufio.Writer, s string)   { fmt.Fprintf(w, "$%d\r\n%s\r\n", len(s), s) }
+
+// globMatch implements the Redis KEYS pattern syntax: * ? [abc] [a-z]
+// [^a] and backslash escapes. Unlike path.Match, '/' is not special.
+func globMatch(pattern, s string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 0 && pattern[0] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if globMatch(pattern, s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+			pattern, s = pattern[1:], s[1:]
+		case '[':
+			if len(s) == 0 {
+				return false
+			}
+			end := strings.IndexByte(pattern[1:], ']')
+			if end < 0 {
+				// '[' sem fechamento é literal
+				if s[0] != '[' {
+					return false
+				}
+				pattern, s = pattern[1:], s[1:]
+				continue
+			}
+			class := pattern[1 : end+1]
+			if !matchClass(class, s[0]) {
+				return false
+			}
+			pattern, s = pattern[end+2:], s[1:]
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(s) == 0 || s[0] != pattern[0] {
+				return false
+			}
+			pattern, s = pattern[1:], s[1:]
+		}
+	}
+	return len(s) == 0
+}
+
+func matchClass(class string, c byte) bool {
+	negate := len(class) > 0 && class[0] == '^'
+	if negate {
+		class = class[1:]
+	}
+	matched := false
+	for i := 0; i < len(class); i++ {
+		if i+2 < len(class) && class[i+1] == '-' {
+			lo, hi := class[i], class[i+2]
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+			if lo <= c && c <= hi {
+				matched = true
+			}
+			i += 2
+			continue
+		}
+		if class[i] == c {
+			matched = true
+		}
+	}
+	return matched != negate
+}
+
+// respComponent runs a respServer as a Lifecycle component.
+type respComponent struct {
+	addr string
+	srv  *respServer
+}
+
+func (c *respComponent) Start(ctx context.Context) error {
+	l, err := net.Listen("tcp", c.addr)
+	if err != nil {
+		return err
+	}
+	log.Printf("singleton RESP em %s (redis-cli -p %s)", l.Addr(), portOf(l.Addr()))
+	go func() {
+		if err := c.srv.Serve(l); err != nil {
+			log.Printf("resp: %v", err)
+		}
+	}()
+	return nil
+}
+
+func (c *respComponent) Stop(ctx context.Context) error {
+	return c.srv.Shutdown(ctx)
+}
+
+// runRESPServer implements "go run . resp": it serves the process
+// singleton over RESP until SIGINT/SIGTERM.
+func runRESPServer(args []string) error {
+	fs := flag.NewFlagSet("resp", flag.ContinueOnError)
+	addr := fs.String("addr", "localhost:6380", "endereço de escuta")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	store := GetInstance_example_3()
+	m := NewLifecycleManager()
+	m.Add("store", store)
+	m.Add("resp", &respComponent{addr: *addr, srv: newRESPServer(store)}, "store")
+	return m.Run(context.Background(), 5*time.Second)
+}
+
+func portOf(addr net.Addr) string {
+	if tcp, ok := addr.(*net.TCPAddr); ok {
+		return strconv.Itoa(tcp.Port)
+	}
+	return addr.String()
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func respReply(srv *respServer, args ...string) string {
+	var b strings.Builder
+	w := bufio.NewWriter(&b)
+	srv.execute(w, args)
+	w.Flush()
+	return b.String()
+}
+
+func TestRESPExpireRange(t *testing.T) {
+	srv := newRESPServer(newSingleton())
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"SET", "k", "v", "EX", "10"}, "+OK\r\n"},
+		{[]string{"SET", "k", "v", "PX", "1500"}, "+OK\r\n"},
+		{[]string{"SET", "k", "v", "EX", "9223372036854775807"}, "-ERR invalid expire time in 'set' command\r\n"},
+		{[]string{"SET", "k", "v", "EX", "9300000000"}, "-ERR invalid expire time in 'set' command\r\n"},
+		{[]string{"SET", "k", "v", "PX", "9223372036855"}, "-ERR invalid expire time in 'set' command\r\n"},
+		{[]string{"SET", "k", "v", "EX", "0"}, "-ERR invalid expire time in 'set' command\r\n"},
+		{[]string{"SET", "k", "v", "EX", "99999999999999999999"}, "-ERR value is not an integer or out of range\r\n"},
+		{[]string{"EXPIRE", "k", "9300000000"}, "-ERR invalid expire time in 'expire' command\r\n"},
+		{[]string{"EXPIRE", "k", "-9300000000"}, "-ERR invalid expire time in 'expire' command\r\n"},
+		{[]string{"EXPIRE", "k", "100"}, ":1\r\n"},
+		{[]string{"TTL", "k"}, ":100\r\n"},
+	}
+	for _, tt := range tests {
+		if got := respReply(srv, tt.args...); got != tt.want {
+			t.Errorf("%q = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestRESPDelAndTTL(t *testing.T) {
+	s := newSingleton()
+	srv := newRESPServer(s)
+	s.Set("a", "1")
+	s.Set("b", "2")
+	if got := respReply(srv, "DEL", "a", "b", "a", "nada"); got != ":2\r\n" {
+		t.Errorf("DEL = %q, want :2", got)
+	}
+	if s.Size() != 0 {
+		t.Errorf("size after DEL = %d", s.Size())
+	}
+
+	// TTL arredonda para o segundo mais próximo, como o Redis
+	tests := []struct {
+		ttl  time.Duration
+		want string
+	}{
+		{10*time.Second + 400*time.Millisecond, ":10\r\n"},
+		{10*time.Second + 600*time.Millisecond, ":11\r\n"},
+	}
+	for _, tt := range tests {
+		s.SetWithTTL("k", "v", tt.ttl)
+		if got := respReply(srv, "TTL", "k"); got != tt.want {
+			t.Errorf("TTL after %v = %q, want %q", tt.ttl, got, tt.want)
+		}
+	}
+}
+
+func TestRESPShutdownBeforeServe(t *testing.T) {
+	srv := newRESPServer(newSingleton())
+	if err := srv.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	if err := srv.Serve(l); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("Serve = %v, want net.ErrClosed", err)
+	}
+	if _, err := l.Accept(); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("listener still open: Accept = %v", err)
+	}
+}
+
+func TestReadRESPCommand(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+		err   error
+	}{
+		{"array", "*2\r\n$3\r\nGET\r\n$1\r\nk\r\n", []string{"GET", "k"}, nil},
+		{"inline", "PING hello\r\n", []string{"PING", "hello"}, nil},
+		{"bulk too large", fmt.Sprintf("*1\r\n$%d\r\n", respMaxBulk+1), nil, errRESPProtocol},
+		{"huge bulk", "*1\r\n$9223372036854775807\r\n", nil, errRESPProtocol},
+		{"announced but not sent", fmt.Sprintf("*1\r\n$%d\r\nabc", respMaxBulk), nil, nil},
+		{"missing CRLF", "*1\r\n$3\r\nGETxx", nil, errRESPProtocol},
+		{"too many args", fmt.Sprintf("*%d\r\n", respMaxArgs+1), nil, errRESPProtocol},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := readRESPCommand(bufio.NewReader(strings.NewReader(tt.input)))
+			if tt.want != nil {
+				if err != nil || strings.Join(args, " ") != strings.Join(tt.want, " ") {
+					t.Fatalf("got %q, %v; want %q", args, err, tt.want)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("got %q, want an error", args)
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
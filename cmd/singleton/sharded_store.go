@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"hash/maphash"
+	"time"
+)
+
+var ErrSharedPolicy = errors.New("sharded: policy cannot be split between shards")
+
+// kvStore is the method set shared by the single-mutex and sharded
+// string stores, so callers can swap one for the other.
+type kvStore interface {
+	Set(key, value string)
+	Get(key string) (string, bool)
+	Delete(key string)
+	Size() int
+}
+
+var (
+	_ kvStore = (*singleton)(nil)
+	_ kvStore = (*ShardedStore[string, string])(nil)
+)
+
+// ShardedStore spreads keys over independent Store shards chosen by key
+// hash, so writers to different shards never wait on the same RWMutex.
+type ShardedStore[K comparable, V any] struct {
+	seed   maphash.Seed
+	shards []*Store[K, V]
+}
+
+// NewShardedStore creates a store with n shards (at least one).
+// MaxEntries and MaxBytes are split evenly between the shards and each
+// shard evicts on its own. A policy instance cannot be shared, so
+// cfg.Policy only picks the kind: every shard gets a new, empty policy of
+// that kind. That works for the built-in policies; any other policy
+// returns ErrSharedPolicy.
+func NewShardedStore[K comparable, V any](n int, cfg StoreConfig[K, V]) (*ShardedStore[K, V], error) {
+	if n < 1 {
+		n = 1
+	}
+	var newPolicy func() EvictionPolicy[K]
+	if cfg.Policy != nil {
+		cloner, ok := cfg.Policy.(policyCloner[K])
+		if !ok {
+			return nil, fmt.Errorf("%w: %T", ErrSharedPolicy, cfg.Policy)
+		}
+		newPolicy = cloner.fresh
+	}
+	cfg.MaxEntries = ceilDiv(cfg.MaxEntries, n)
+	cfg.MaxBytes = ceilDiv(cfg.MaxBytes, n)
+
+	s := &ShardedStore[K, V]{
+		seed:   maphash.MakeSeed(),
+		shards: make([]*Store[K, V], n),
+	}
+	for i := range s.shards {
+		if newPolicy != nil {
+			cfg.Policy = newPolicy()
+		}
+		s.shards[i] = NewStoreWithConfig(cfg)
+	}
+	return s, nil
+}
+
+func newShardedSingleton(n int) *ShardedStore[string, string] {
+	// Sem política, NewShardedStore não falha
+	s, _ := NewShardedStore(n, StoreConfig[string, string]{})
+	return s
+}
+
+func ceilDiv(a, b int) int {
+	if a <= 0 {
+		return 0
+	}
+	return (a + b - 1) / b
+}
+
+func (s *ShardedStore[K, V]) shard(key K) *Store[K, V] {
+	h := maphash.Comparable(s.seed, key)
+	return s.shards[h%uint64(len(s.shards))]
+}
+
+func (s *ShardedStore[K, V]) Set(key K, value V) {
+	s.shard(key).Set(key, value)
+}
+
+func (s *ShardedStore[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	s.shard(key).SetWithTTL(key, value, ttl)
+}
+
+func (s *ShardedStore[K, V]) Get(key K) (V, bool) {
+	return s.shard(key).Get(key)
+}
+
+func (s *ShardedStore[K, V]) Delete(key K) {
+	s.shard(key).Delete(key)
+}
+
+// Size sums the live entries of every shard. Shards are read one after
+// the other, so the total is not an atomic snapshot under concurrent
+// writes.
+func (s *ShardedStore[K, V]) Size() int {
+	total := 0
+	for _, shard := range s.shards {
+		total += shard.Size()
+	}
+	return total
+}
+
+// StartJanitor starts one expiry janitor per shard.
+func (s *ShardedStore[K, V]) StartJanitor(interval time.Duration) {
+	for _, shard := range s.shards {
+		shard.StartJanitor(interval)
+	}
+}
+
+func (s *ShardedStore[K, V]) StopJanitor() {
+	for _, shard := range s.shards {
+		shard.StopJanitor()
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"testing"
+)
+
+// syncMapStore adapts sync.Map to kvStore for comparison only.
+type syncMapStore struct {
+	m sync.Map
+}
+
+func (s *syncMapStore) Set(key, value string) { s.m.Store(key, value) }
+
+func (s *syncMapStore) Get(key string) (string, bool) {
+	v, ok := s.m.Load(key)
+	if !ok {
+		return "", false
+	}
+	return v.(string), true
+}
+
+func (s *syncMapStore) Delete(key string) { s.m.Delete(key) }
+
+func (s *syncMapStore) Size() int {
+	n := 0
+	s.m.Range(func(_, _ any) bool {
+		n++
+		return true
+	})
+	return n
+}
+
+const benchKeys = 1 << 12
+
+var benchKeyNames = func() []string {
+	keys := make([]string, benchKeys)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key_%d", i)
+	}
+	return keys
+}()
+
+// BenchmarkStores compares the single-mutex singleton, the sharded store
+// and sync.Map. SetParallelism(100) runs 100 goroutines per GOMAXPROCS to
+// reproduce the "hundreds of writers" case.
+func BenchmarkStores(b *testing.B) {
+	stores := []struct {
+		name string
+		new  func() kvStore
+	}{
+		{"mutex", func() kvStore { return newSingleton() }},
+		{"sharded_16", func() kvStore { return newShardedSingleton(16) }},
+		{"sharded_64", func() kvStore { return newShardedSingleton(64) }},
+		{"sync_map", func() kvStore { return &syncMapStore{} }},
+	}
+	loads := []struct {
+		name       string
+		writeRatio int // Porcentagem de escritas
+	}{
+		{"read_heavy", 10},
+		{"mixed", 50},
+		{"write_heavy", 90},
+	}
+
+	for _, load := range loads {
+		for _, st := range stores {
+			b.Run(load.name+"/"+st.name, func(b *testing.B) {
+				store := st.new()
+				for _, k := range benchKeyNames {
+					store.Set(k, k)
+				}
+				b.SetParallelism(100)
+				b.ResetTimer()
+				b.RunParallel(func(pb *testing.PB) {
+					r := rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+					for pb.Next() {
+						key := benchKeyNames[r.IntN(benchKeys)]
+						if r.IntN(100) < load.writeRatio {
+							store.Set(key, key)
+						} else {
+							store.Get(key)
+						}
+					}
+				})
+			})
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+// keysOfShard returns n keys that s routes to shard i.
+func keysOfShard(s *ShardedStore[string, string], i, n int) []string {
+	var keys []string
+	for k := 0; len(keys) < n; k++ {
+		key := "k" + strconv.Itoa(k)
+		if s.shard(key) == s.shards[i] {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func TestShardedRouting(t *testing.T) {
+	s := newShardedSingleton(8)
+	used := make(map[*Store[string, string]]bool)
+	for k := range 200 {
+		key := strconv.Itoa(k)
+		if s.shard(key) != s.shard(key) {
+			t.Fatalf("key %q routed to two shards", key)
+		}
+		s.Set(key, "v"+key)
+		used[s.shard(key)] = true
+	}
+	if len(used) < 2 {
+		t.Errorf("200 keys went to %d shard(s)", len(used))
+	}
+	for k := range 200 {
+		key := strconv.Itoa(k)
+		if v, ok := s.Get(key); !ok || v != "v"+key {
+			t.Fatalf("Get(%q) = %q, %t", key, v, ok)
+		}
+		// A chave só existe no shard dela
+		for _, shard := range s.shards {
+			if _, ok := shard.Get(key); ok != (shard == s.shard(key)) {
+				t.Fatalf("key %q found in the wrong shard", key)
+			}
+		}
+	}
+	s.Delete("0")
+	if _, ok := s.Get("0"); ok || s.Size() != 199 {
+		t.Errorf("after Delete: size %d", s.Size())
+	}
+
+	if s := newShardedSingleton(0); len(s.shards) != 1 {
+		t.Errorf("n = 0 gave %d shards, want 1", len(s.shards))
+	}
+}
+
+func TestShardedCapacityPerShard(t *testing.T) {
+	s, err := NewShardedStore(4, StoreConfig[string, string]{MaxEntries: 7}) // 2 por shard
+	if err != nil {
+		t.Fatal(err)
+	}
+	full := keysOfShard(s, 0, 3)
+	other := keysOfShard(s, 1, 2)
+	for _, key := range append(other, full...) {
+		s.Set(key, "v")
+	}
+	// O shard 0 passou do limite dele e despejou sozinho; o 1 não foi tocado
+	if n := s.shards[0].Size(); n != 2 {
+		t.Errorf("shard 0 holds %d keys, want its limit of 2", n)
+	}
+	if _, ok := s.Get(full[0]); ok {
+		t.Errorf("oldest key %q of shard 0 was kept", full[0])
+	}
+	for _, key := range other {
+		if _, ok := s.Get(key); !ok {
+			t.Errorf("key %q of shard 1 was evicted", key)
+		}
+	}
+}
+
+func TestShardedPolicyPerShard(t *testing.T) {
+	s, err := NewShardedStore(4, StoreConfig[string, string]{MaxEntries: 8, Policy: NewFIFOPolicy[string]()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, shard := range s.shards {
+		if _, ok := shard.policy.(*fifoPolicy[string]); !ok {
+			t.Fatalf("shard %d policy is %T, want FIFO", i, shard.policy)
+		}
+		if i > 0 && shard.policy == s.shards[0].policy {
+			t.Fatal("shards share a policy instance")
+		}
+	}
+
+	keys := keysOfShard(s, 2, 3)
+	s.Set(keys[0], "v")
+	s.Set(keys[1], "v")
+	s.Get(keys[0]) // Leitura não salva a mais antiga na FIFO
+	s.Set(keys[2], "v")
+	if _, ok := s.Get(keys[0]); ok {
+		t.Errorf("FIFO kept %q after a read; LRU would", keys[0])
+	}
+
+	_, err = NewShardedStore(4, StoreConfig[string, string]{Policy: customPolicy{}})
+	if !errors.Is(err, ErrSharedPolicy) {
+		t.Errorf("custom policy: err = %v, want ErrSharedPolicy", err)
+	}
+}
+
+// customPolicy is a policy the sharded store does not know how to copy.
+type customPolicy struct{}
+
+func (customPolicy) Added(string)           {}
+func (customPolicy) Accessed(string)        {}
+func (customPolicy) Removed(string)         {}
+func (customPolicy) Victim() (string, bool) { return "", false }
@@ -3,14 +3,65 @@ package main
 import (
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
+// singleton is the string store shared through the GetInstance_example_N
+// functions. It is a thin wrapper over Store[string, string].
 type singleton struct {
-	data  map[string]string
-	mutex sync.RWMutex // Protege operações no map
+	Store[string, string]
+
+	autoSnapshotMu   sync.Mutex
+	autoSnapshotStop chan struct{}
+	autoSnapshotDone chan error
+
+	wal atomic.Pointer[writeAheadLog] // nil quando a durabilidade está desligada
+
+	namespaces namespaceTable // Protegido pelo mutex do Store
+}
+
+// Option configures a singleton store at construction time.
+type Option func(*StoreConfig[string, string])
+
+// WithMaxEntries bounds the number of entries kept in the store.
+func WithMaxEntries(n int) Option {
+	return func(c *StoreConfig[string, string]) { c.MaxEntries = n }
+}
+
+// WithMaxBytes bounds the sum of len(key)+len(value) over all entries.
+func WithMaxBytes(n int) Option {
+	return func(c *StoreConfig[string, string]) { c.MaxBytes = n }
 }
 
-var once sync.Once
+// WithEvictionPolicy selects which key is dropped when a limit is hit.
+// Defaults to LRU when a limit is set without a policy.
+func WithEvictionPolicy(p EvictionPolicy[string]) Option {
+	return func(c *StoreConfig[string, string]) { c.Policy = p }
+}
+
+// WithOnEvict registers a callback for entries dropped to respect the
+// capacity limits. It runs after the store lock has been released.
+func WithOnEvict(fn func(key, value string)) Option {
+	return func(c *StoreConfig[string, string]) { c.OnEvict = fn }
+}
+
+// WithLatencyTracking turns on the latency histograms of Stats.
+func WithLatencyTracking() Option {
+	return func(c *StoreConfig[string, string]) { c.TrackLatency = true }
+}
+
+func newSingleton(opts ...Option) *singleton {
+	var cfg StoreConfig[string, string]
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	s := &singleton{}
+	s.init(cfg)
+	return s
+}
+
+// Ponteiro para que os testes troquem o Once inteiro, com o estado dele
+var once = new(sync.Once)
 var lock = &sync.Mutex{}
 var atomicinz uint64
 
@@ -21,9 +72,7 @@ var (
 // GetInstance returns the singleton instance (non-thread-safe)
 func GetInstance_example_1() *singleton {
 	if instance == nil {
-		instance = &singleton{
-			data: make(map[string]string),
-		}
+		instance = newSingleton()
 	}
 	return instance
 }
@@ -33,9 +82,7 @@ func GetInstance_example_2() *singleton {
 	lock.Lock()
 	defer lock.Unlock()
 	if instance == nil {
-		instance = &singleton{
-			data: make(map[string]string),
-		}
+		instance = newSingleton()
 	}
 	return instance
 }
@@ -44,9 +91,7 @@ func GetInstance_example_2() *singleton {
 // Best choice for race condition
 func GetInstance_example_3() *singleton {
 	once.Do(func() {
-		instance = &singleton{
-			data: make(map[string]string),
-		}
+		instance = newSingleton()
 	})
 	return instance
 }
@@ -59,54 +104,70 @@ func GetInstance_example_4() *singleton {
 	lock.Lock()
 	defer lock.Unlock()
 	if atomic.LoadUint64(&atomicinz) == 0 {
-		instance = &singleton{
-			data: make(map[string]string),
-		}
+		instance = newSingleton()
 		atomic.StoreUint64(&atomicinz, 1)
 	}
 
 	return instance
 }
 
-// Métodos para interagir com o singleton - THREAD SAFE
-func (s *singleton) Set(key, value string) {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
-
-	if s.data == nil {
-		s.data = make(map[string]string)
-	}
-	s.data[key] = value
+// GetInstance returns the singleton instance (thread-safe with
+// sync.OnceValue): sync.Once plus the variable it guards in one value.
+func GetInstance_example_5() *singleton {
+	return instanceValue()
 }
 
-func (s *singleton) Get(key string) (string, bool) {
-	s.mutex.RLock()
-	defer s.mutex.RUnlock()
+var instanceValue = sync.OnceValue(func() *singleton { return newSingleton() })
 
-	if s.data == nil {
-		return "", false
+// GetInstance returns the singleton instance (lock-free with
+// atomic.Pointer). Racing first callers may each build a candidate, but
+// only the one that wins the CompareAndSwap is ever handed out.
+func GetInstance_example_6() *singleton {
+	if s := instancePtr.Load(); s != nil {
+		return s
 	}
-	value, exists := s.data[key]
-	return value, exists
+	instancePtr.CompareAndSwap(nil, newSingleton())
+	return instancePtr.Load()
 }
 
-func (s *singleton) Delete(key string) {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
+var instancePtr atomic.Pointer[singleton]
+
+// Set, SetWithTTL and Delete shadow the Store methods so that mutations
+// are recorded in the write-ahead log when durability is enabled.
+func (s *singleton) Set(key, value string) {
+	s.setWithDeadline(key, value, time.Time{})
+}
 
-	if s.data != nil {
-		delete(s.data, key)
+func (s *singleton) SetWithTTL(key, value string, ttl time.Duration) {
+	if ttl <= 0 {
+		s.Set(key, value)
+		return
 	}
+	s.setWithDeadline(key, value, time.Now().Add(ttl))
 }
 
-func (s *singleton) Size() int {
-	s.mutex.RLock()
-	defer s.mutex.RUnlock()
+func (s *singleton) setWithDeadline(key, value string, deadline time.Time) {
+	w := s.wal.Load()
+	if w == nil {
+		s.Store.setWithDeadline(key, value, deadline)
+		return
+	}
+	w.apply(func() []storeMutation[string, string] {
+		s.Store.setWithDeadline(key, value, deadline)
+		return []storeMutation[string, string]{{Key: key, Value: value, Deadline: deadline}}
+	})
+}
 
-	if s.data == nil {
-		return 0
+func (s *singleton) Delete(key string) {
+	w := s.wal.Load()
+	if w == nil {
+		s.Store.Delete(key)
+		return
 	}
-	return len(s.data)
+	w.apply(func() []storeMutation[string, string] {
+		s.Store.Delete(key)
+		return []storeMutation[string, string]{{Key: key, Delete: true}}
+	})
 }
 
 // withtout singleton
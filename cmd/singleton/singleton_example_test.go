@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+var getInstanceVariants = []struct {
+	name       string
+	get        func() *singleton
+	concurrent bool // Seguro para a primeira chamada concorrente
+}{
+	{"example_1_unsynchronized", GetInstance_example_1, false},
+	{"example_2_mutex", GetInstance_example_2, true},
+	{"example_3_once", GetInstance_example_3, true},
+	{"example_4_atomic", GetInstance_example_4, true},
+	{"example_5_once_value", GetInstance_example_5, true},
+	{"example_6_atomic_pointer", GetInstance_example_6, true},
+}
+
+func TestGetInstanceReturnsSameInstance(t *testing.T) {
+	for _, v := range getInstanceVariants {
+		t.Run(v.name, func(t *testing.T) {
+			withFreshInstance(t)
+
+			first := v.get()
+			if first == nil {
+				t.Fatal("GetInstance returned nil")
+			}
+			if second := v.get(); second != first {
+				t.Fatalf("second call returned %p, want %p", second, first)
+			}
+		})
+	}
+}
+
+func TestGetInstanceStartsEmpty(t *testing.T) {
+	for _, v := range getInstanceVariants {
+		t.Run(v.name, func(t *testing.T) {
+			withFreshInstance(t)
+
+			s := v.get()
+			if n := s.Size(); n != 0 {
+				t.Fatalf("Size() = %d on a fresh instance, want 0", n)
+			}
+			// Grava algo para provar que o próximo teste não herda este estado
+			s.Set("leaked", "yes")
+		})
+	}
+}
+
+func TestGetInstanceMethods(t *testing.T) {
+	for _, v := range getInstanceVariants {
+		t.Run(v.name, func(t *testing.T) {
+			withFreshInstance(t)
+
+			s := v.get()
+			s.Set("nome", "João")
+			if got, ok := v.get().Get("nome"); !ok || got != "João" {
+				t.Fatalf(`Get("nome") = %q, %t; want "João", true`, got, ok)
+			}
+			s.Delete("nome")
+			if _, ok := s.Get("nome"); ok {
+				t.Fatal("key still present after Delete")
+			}
+			if n := s.Size(); n != 0 {
+				t.Fatalf("Size() = %d, want 0", n)
+			}
+		})
+	}
+}
+
+func TestGetInstanceConcurrentFirstCall(t *testing.T) {
+	for _, v := range getInstanceVariants {
+		t.Run(v.name, func(t *testing.T) {
+			if !v.concurrent {
+				t.Skip("exemplo 1 não é seguro na primeira chamada concorrente")
+			}
+			withFreshInstance(t)
+
+			const goroutines = 100
+			got := make([]*singleton, goroutines)
+			start := make(chan struct{})
+			var wg sync.WaitGroup
+			for i := range goroutines {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					<-start
+					s := v.get()
+					s.Set(fmt.Sprintf("goroutine_%d", i), "ok")
+					got[i] = s
+				}()
+			}
+			close(start)
+			wg.Wait()
+
+			for i, s := range got {
+				if s != got[0] {
+					t.Fatalf("goroutine %d got %p, goroutine 0 got %p", i, s, got[0])
+				}
+			}
+			if n := got[0].Size(); n != goroutines {
+				t.Fatalf("Size() = %d, want %d", n, goroutines)
+			}
+		})
+	}
+}
+
+func TestWithFreshInstanceRestoresPrevious(t *testing.T) {
+	withFreshInstance(t)
+	outer := GetInstance_example_3()
+	outer.Set("k", "v")
+	outerStore := GetStore[int, string]()
+
+	restore := swapFreshInstance()
+	if GetInstance_example_3() == outer {
+		t.Fatal("fresh globals returned the previous instance")
+	}
+	if GetStore[int, string]() == outerStore {
+		t.Fatal("fresh globals returned the previous typed store")
+	}
+	restore()
+
+	if got := GetStore[int, string](); got != outerStore {
+		t.Fatalf("typed store after restore = %p, want %p", got, outerStore)
+	}
+
+	if got := GetInstance_example_3(); got != outer {
+		t.Fatalf("instance after restore = %p, want %p", got, outer)
+	}
+	if v, ok := GetInstance_example_3().Get("k"); !ok || v != "v" {
+		t.Fatalf(`Get("k") after restore = %q, %t; want "v", true`, v, ok)
+	}
+}
+
+func TestWithFreshInstanceRestoresOnce(t *testing.T) {
+	withFreshInstance(t)
+
+	// Once ainda não executado: depois do restore, o exemplo 3 precisa
+	// criar a instância, e não ficar preso em nil
+	saved := once
+	restore := swapFreshInstance()
+	if once == saved {
+		t.Fatal("fresh globals kept the previous Once")
+	}
+	GetInstance_example_3()
+	restore()
+	if once != saved {
+		t.Fatal("restore did not put the previous Once back")
+	}
+	if GetInstance_example_3() == nil {
+		t.Fatal("restored Once reported done without an instance")
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// globalsInUse is set while a test owns the package-level singleton
+// state through withFreshInstance. Those globals are shared by the whole
+// package, so such tests cannot run in parallel; the flag turns an
+// accidental t.Parallel or a nested call into a test failure instead of
+// a data race.
+var globalsInUse atomic.Bool
+
+// resetInstance puts the GetInstance_example_N globals back to their
+// initial state. Test-only: production code must never reset a singleton.
+func resetInstance() {
+	lock.Lock()
+	defer lock.Unlock()
+
+	instance = nil
+	once = new(sync.Once)
+	atomic.StoreUint64(&atomicinz, 0)
+	instanceValue = sync.OnceValue(func() *singleton { return newSingleton() })
+	instancePtr.Store(nil)
+	typedStores.Clear()
+	defaultRegistry = NewRegistry()
+}
+
+// withFreshInstance gives the calling test pristine singleton globals and
+// restores the previous ones on cleanup. Tests using it must not call
+// t.Parallel, and it must not be nested inside another test that already
+// called it: both fail the test.
+func withFreshInstance(tb testing.TB) {
+	tb.Helper()
+	if !globalsInUse.CompareAndSwap(false, true) {
+		tb.Fatal("withFreshInstance: singleton globals already in use by another test (parallel or nested)")
+	}
+	restore := swapFreshInstance()
+	tb.Cleanup(func() {
+		defer globalsInUse.Store(false)
+		restore()
+	})
+}
+
+// swapFreshInstance resets the globals and returns a function that puts
+// the previous ones back. Callers must own the globals, through
+// withFreshInstance or by running alone.
+func swapFreshInstance() (restore func()) {
+	lock.Lock()
+	savedInstance := instance
+	savedOnce := once
+	savedFlag := atomic.LoadUint64(&atomicinz)
+	savedRegistry := defaultRegistry
+	savedValue := instanceValue
+	savedPtr := instancePtr.Load()
+	lock.Unlock()
+	savedStores := make(map[any]any)
+	typedStores.Range(func(k, v any) bool {
+		savedStores[k] = v
+		return true
+	})
+
+	resetInstance()
+
+	return func() {
+		resetInstance()
+
+		lock.Lock()
+		defer lock.Unlock()
+		instance = savedInstance
+		once = savedOnce
+		atomic.StoreUint64(&atomicinz, savedFlag)
+		defaultRegistry = savedRegistry
+		instanceValue = savedValue
+		instancePtr.Store(savedPtr)
+		for k, v := range savedStores {
+			typedStores.Store(k, v)
+		}
+	}
+}
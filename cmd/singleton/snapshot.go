@@ -0,0 +1,398 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// SnapshotFormat selects the encoding written by SaveSnapshotAs.
+type SnapshotFormat int
+
+const (
+	SnapshotBinary SnapshotFormat = iota
+	SnapshotJSON
+)
+
+const snapshotVersion = 1
+
+// snapshotMagic opens every binary snapshot. JSON snapshots start with
+// '{', so LoadSnapshot can tell both formats apart.
+var snapshotMagic = []byte("SGSN")
+
+// maxSnapshotString bounds a key or value read from a binary snapshot,
+// as maxWALRecord does for the WAL; snapshotReadChunk is how much is
+// allocated up front for one.
+const (
+	maxSnapshotString = 64 << 20
+	snapshotReadChunk = 64 << 10
+)
+
+var (
+	ErrSnapshotChecksum = errors.New("snapshot: checksum mismatch")
+	ErrSnapshotVersion  = errors.New("snapshot: unsupported version")
+	ErrSnapshotFormat   = errors.New("snapshot: unknown format")
+)
+
+// jsonSnapshot is the on-disk JSON layout. Checksum is the CRC-32 of the
+// JSON encoding of Entries.
+type jsonSnapshot struct {
+	Version  int                 `json:"version"`
+	Checksum uint32              `json:"checksum"`
+	Entries  []jsonSnapshotEntry `json:"entries"`
+}
+
+type jsonSnapshotEntry struct {
+	Key       string `json:"key"`
+	Value     string `json:"value"`
+	ExpiresAt int64  `json:"expires_at,omitempty"` // Unix nanos; 0 = sem TTL
+}
+
+// SaveSnapshot writes every live entry to w in the binary format.
+func (s *singleton) SaveSnapshot(w io.Writer) error {
+	return s.SaveSnapshotAs(w, SnapshotBinary)
+}
+
+// SaveSnapshotAs writes every live entry to w in the given format.
+// Remaining TTLs are kept as absolute deadlines.
+func (s *singleton) SaveSnapshotAs(w io.Writer, format SnapshotFormat) error {
+	entries := s.entries()
+	switch format {
+	case SnapshotBinary:
+		return writeBinarySnapshot(w, entries)
+	case SnapshotJSON:
+		return writeJSONSnapshot(w, entries)
+	}
+	return ErrSnapshotFormat
+}
+
+// LoadSnapshot replaces the store contents with a snapshot read from r.
+// The format is detected from the first bytes; entries whose deadline
+// has passed are dropped. The store is left untouched on error.
+//
+// The replacement is not a logged write, so with durability enabled the
+// new contents are checkpointed as Compact does: saved to the snapshot
+// file and the log truncated. If that fails the store keeps the loaded
+// contents but is no longer durable, and the error wraps ErrNotDurable.
+func (s *singleton) LoadSnapshot(r io.Reader) error {
+	br := bufio.NewReader(r)
+	head, err := br.Peek(len(snapshotMagic))
+	if err != nil && !(errors.Is(err, io.EOF) && len(head) > 0) {
+		return fmt.Errorf("snapshot: read header: %w", err)
+	}
+
+	var entries []storeEntry[string, string]
+	switch {
+	case bytes.Equal(head, snapshotMagic):
+		entries, err = readBinarySnapshot(br)
+	case bytes.HasPrefix(bytes.TrimLeft(head, " \t\r\n"), []byte("{")):
+		entries, err = readJSONSnapshot(br)
+	default:
+		err = ErrSnapshotFormat
+	}
+	if err != nil {
+		return err
+	}
+
+	w := s.wal.Load()
+	if w == nil {
+		s.replace(entries)
+		return nil
+	}
+	// O lock do log segura os Sets concorrentes até o checkpoint
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	s.replace(entries)
+	if err := w.compactLocked(); err != nil {
+		w.failLocked(fmt.Errorf("checkpoint after load: %w", err))
+		return w.err
+	}
+	return nil
+}
+
+// SaveSnapshotFile writes a snapshot to path atomically: the data goes to
+// a temporary file in the same directory, which is synced and renamed.
+func (s *singleton) SaveSnapshotFile(path string, format SnapshotFormat) error {
+	return writeFileAtomic(path, func(w io.Writer) error {
+		return s.SaveSnapshotAs(w, format)
+	})
+}
+
+// LoadSnapshotFile restores the store from path. A missing file is not
+// an error: the store simply starts empty.
+func (s *singleton) LoadSnapshotFile(path string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return s.LoadSnapshot(f)
+}
+
+// StartAutoSnapshot saves a snapshot to path every interval until
+// StopAutoSnapshot is called. Calling it while already running is a no-op.
+func (s *singleton) StartAutoSnapshot(path string, interval time.Duration, format SnapshotFormat) {
+	s.autoSnapshotMu.Lock()
+	defer s.autoSnapshotMu.Unlock()
+
+	if s.autoSnapshotStop != nil || interval <= 0 {
+		return
+	}
+	stop := make(chan struct{})
+	done := make(chan error, 1)
+	s.autoSnapshotStop, s.autoSnapshotDone = stop, done
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.SaveSnapshotFile(path, format); err != nil {
+					log.Printf("auto-snapshot %s: %v", path, err)
+				}
+			case <-stop:
+				// Último snapshot para não perder o que mudou desde o tick
+				done <- s.SaveSnapshotFile(path, format)
+				return
+			}
+		}
+	}()
+}
+
+// StopAutoSnapshot stops the auto-snapshot goroutine after one final
+// save and returns the error of that save.
+func (s *singleton) StopAutoSnapshot() error {
+	s.autoSnapshotMu.Lock()
+	defer s.autoSnapshotMu.Unlock()
+
+	if s.autoSnapshotStop == nil {
+		return nil
+	}
+	close(s.autoSnapshotStop)
+	err := <-s.autoSnapshotDone
+	s.autoSnapshotStop, s.autoSnapshotDone = nil, nil
+	return err
+}
+
+// writeFileAtomic writes path through a temp file and rename, so readers
+// see either the old or the new contents, never a partial file.
+func writeFileAtomic(path string, write func(io.Writer) error) (err error) {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	bw := bufio.NewWriter(tmp)
+	if err = write(bw); err != nil {
+		return err
+	}
+	if err = bw.Flush(); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	// Persiste a entrada do diretório; nem todo sistema suporta, então é best effort
+	if d, derr := os.Open(dir); derr == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
+func writeJSONSnapshot(w io.Writer, entries []storeEntry[string, string]) error {
+	snap := jsonSnapshot{Version: snapshotVersion, Entries: make([]jsonSnapshotEntry, len(entries))}
+	for i, e := range entries {
+		snap.Entries[i] = jsonSnapshotEntry{Key: e.Key, Value: e.Value, ExpiresAt: unixNanoOrZero(e.Deadline)}
+	}
+	payload, err := json.Marshal(snap.Entries)
+	if err != nil {
+		return err
+	}
+	snap.Checksum = crc32.ChecksumIEEE(payload)
+	return json.NewEncoder(w).Encode(snap)
+}
+
+func readJSONSnapshot(r io.Reader) ([]storeEntry[string, string], error) {
+	var snap jsonSnapshot
+	if err := json.NewDecoder(r).Decode(&snap); err != nil {
+		return nil, fmt.Errorf("snapshot: decode json: %w", err)
+	}
+	if snap.Version != snapshotVersion {
+		return nil, fmt.Errorf("%w: %d", ErrSnapshotVersion, snap.Version)
+	}
+	payload, err := json.Marshal(snap.Entries)
+	if err != nil {
+		return nil, err
+	}
+	if crc32.ChecksumIEEE(payload) != snap.Checksum {
+		return nil, ErrSnapshotChecksum
+	}
+
+	entries := make([]storeEntry[string, string], len(snap.Entries))
+	for i, e := range snap.Entries {
+		entries[i] = storeEntry[string, string]{Key: e.Key, Value: e.Value, Deadline: timeOrZero(e.ExpiresAt)}
+	}
+	return entries, nil
+}
+
+// Binary layout (integers are varints unless noted):
+//
+//	magic "SGSN" | version | count | count × (key, value, expiresAt) | crc32 (uint32 LE)
+//
+// Strings are length-prefixed, expiresAt is Unix nanos (0 = sem TTL) and
+// the CRC-32 covers every byte before it.
+func writeBinarySnapshot(w io.Writer, entries []storeEntry[string, string]) error {
+	crc := crc32.NewIEEE()
+	bw := bufio.NewWriter(io.MultiWriter(w, crc))
+
+	var buf [binary.MaxVarintLen64]byte
+	putUvarint := func(v uint64) { bw.Write(buf[:binary.PutUvarint(buf[:], v)]) }
+	putString := func(v string) {
+		putUvarint(uint64(len(v)))
+		bw.WriteString(v)
+	}
+
+	bw.Write(snapshotMagic)
+	putUvarint(snapshotVersion)
+	putUvarint(uint64(len(entries)))
+	for _, e := range entries {
+		putString(e.Key)
+		putString(e.Value)
+		bw.Write(buf[:binary.PutVarint(buf[:], unixNanoOrZero(e.Deadline))])
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return binary.Write(w, binary.LittleEndian, crc.Sum32())
+}
+
+func readBinarySnapshot(r *bufio.Reader) ([]storeEntry[string, string], error) {
+	crc := crc32.NewIEEE()
+	tr := &byteTeeReader{r: r, w: crc}
+
+	magic := make([]byte, len(snapshotMagic))
+	if _, err := io.ReadFull(tr, magic); err != nil {
+		return nil, fmt.Errorf("snapshot: read magic: %w", err)
+	}
+	version, err := binary.ReadUvarint(tr)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot: read version: %w", err)
+	}
+	if version != snapshotVersion {
+		return nil, fmt.Errorf("%w: %d", ErrSnapshotVersion, version)
+	}
+	count, err := binary.ReadUvarint(tr)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot: read count: %w", err)
+	}
+
+	// O tamanho vem do arquivo antes do CRC ser conferido: é limitado, e o
+	// buffer cresce com os bytes que de fato chegam, não com o que foi declarado
+	readString := func() (string, error) {
+		n, err := binary.ReadUvarint(tr)
+		if err != nil {
+			return "", err
+		}
+		if n > maxSnapshotString {
+			return "", fmt.Errorf("string of %d bytes exceeds %d", n, maxSnapshotString)
+		}
+		var b strings.Builder
+		b.Grow(int(min(n, snapshotReadChunk)))
+		if _, err := io.CopyN(&b, tr, int64(n)); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return "", err
+		}
+		return b.String(), nil
+	}
+
+	var entries []storeEntry[string, string]
+	for i := uint64(0); i < count; i++ {
+		key, err := readString()
+		if err != nil {
+			return nil, fmt.Errorf("snapshot: entry %d: %w", i, err)
+		}
+		value, err := readString()
+		if err != nil {
+			return nil, fmt.Errorf("snapshot: entry %d: %w", i, err)
+		}
+		expiresAt, err := binary.ReadVarint(tr)
+		if err != nil {
+			return nil, fmt.Errorf("snapshot: entry %d: %w", i, err)
+		}
+		entries = append(entries, storeEntry[string, string]{Key: key, Value: value, Deadline: timeOrZero(expiresAt)})
+	}
+
+	var sum uint32
+	if err := binary.Read(r, binary.LittleEndian, &sum); err != nil {
+		return nil, fmt.Errorf("snapshot: read checksum: %w", err)
+	}
+	if sum != crc.Sum32() {
+		return nil, ErrSnapshotChecksum
+	}
+	return entries, nil
+}
+
+// byteTeeReader is io.TeeReader plus io.ByteReader, which the varint
+// decoders need.
+type byteTeeReader struct {
+	r *bufio.Reader
+	w io.Writer
+}
+
+func (t *byteTeeReader) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	t.w.Write(p[:n])
+	return n, err
+}
+
+func (t *byteTeeReader) ReadByte() (byte, error) {
+	b, err := t.r.ReadByte()
+	if err == nil {
+		t.w.Write([]byte{b})
+	}
+	return b, err
+}
+
+func unixNanoOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+func timeOrZero(nanos int64) time.Time {
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
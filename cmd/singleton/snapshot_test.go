@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func snapshotOf(t *testing.T, format SnapshotFormat) []byte {
+	t.Helper()
+	s := newSingleton()
+	s.Set("a", "1")
+	s.Set("b", strings.Repeat("x", 300))
+	s.SetWithTTL("c", "3", time.Hour)
+	var buf bytes.Buffer
+	if err := s.SaveSnapshotAs(&buf, format); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	for _, format := range []SnapshotFormat{SnapshotBinary, SnapshotJSON} {
+		s := newSingleton()
+		s.Set("stale", "x")
+		if err := s.LoadSnapshot(bytes.NewReader(snapshotOf(t, format))); err != nil {
+			t.Fatalf("format %d: %v", format, err)
+		}
+		if v, _ := s.Get("b"); v != strings.Repeat("x", 300) {
+			t.Errorf("format %d: b = %q", format, v)
+		}
+		if ttl, ok := s.TTL("c"); !ok || ttl <= 0 || ttl > time.Hour {
+			t.Errorf("format %d: TTL(c) = %v, %t", format, ttl, ok)
+		}
+		if _, ok := s.Get("stale"); ok || s.Size() != 3 {
+			t.Errorf("format %d: load did not replace the contents (size %d)", format, s.Size())
+		}
+	}
+}
+
+func TestSnapshotCorrupt(t *testing.T) {
+	good := snapshotOf(t, SnapshotBinary)
+
+	// Cabeçalho com uma entrada cujo tamanho declarado é absurdo
+	huge := append([]byte(nil), snapshotMagic...)
+	huge = binary.AppendUvarint(huge, snapshotVersion)
+	huge = binary.AppendUvarint(huge, 1)
+	huge = binary.AppendUvarint(huge, 1<<62)
+
+	// Tamanho dentro do limite, mas maior que o que resta no arquivo
+	short := append([]byte(nil), snapshotMagic...)
+	short = binary.AppendUvarint(short, snapshotVersion)
+	short = binary.AppendUvarint(short, 1)
+	short = binary.AppendUvarint(short, maxSnapshotString)
+	short = append(short, "abc"...)
+
+	flipped := bytes.Clone(good)
+	flipped[len(snapshotMagic)+4] ^= 0xFF
+
+	tests := []struct {
+		name string
+		data []byte
+		want error // nil: só exige um erro
+	}{
+		{"empty", nil, nil},
+		{"unknown format", []byte("hello"), ErrSnapshotFormat},
+		{"bad version", append(bytes.Clone(snapshotMagic), 9), ErrSnapshotVersion},
+		{"huge length", huge, nil},
+		{"length past the end", short, nil},
+		{"flipped byte", flipped, nil},
+		{"bad checksum", append(bytes.Clone(good[:len(good)-4]), 0, 0, 0, 0), ErrSnapshotChecksum},
+		{"bad json checksum", []byte(`{"version":1,"checksum":1,"entries":[{"key":"a","value":"1"}]}`), ErrSnapshotChecksum},
+		{"bad json", []byte(`{"version":`), nil},
+	}
+	for i := range len(good) {
+		tests = append(tests, struct {
+			name string
+			data []byte
+			want error
+		}{"truncated", good[:i], nil})
+	}
+
+	for _, tt := range tests {
+		s := newSingleton()
+		s.Set("keep", "me")
+		err := s.LoadSnapshot(bytes.NewReader(tt.data))
+		if err == nil {
+			t.Errorf("%s (%d bytes): loaded without error", tt.name, len(tt.data))
+			continue
+		}
+		if tt.want != nil && !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+		if v, ok := s.Get("keep"); !ok || v != "me" || s.Size() != 1 {
+			t.Errorf("%s: store changed on error", tt.name)
+		}
+	}
+}
@@ -0,0 +1,493 @@
+package main
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Store is a thread-safe key/value store with optional TTLs and capacity
+// limits. The zero value is ready to use and unbounded.
+type Store[K comparable, V any] struct {
+	data    map[K]V
+	expires map[K]time.Time // Deadline das chaves gravadas com TTL
+	mutex   sync.RWMutex    // Protege operações no map
+
+	// Limites de capacidade; zero significa ilimitado
+	maxEntries int
+	maxBytes   int
+	bytes      int
+	sizer      func(K, V) int
+	policy     EvictionPolicy[K]
+	onEvict    func(K, V)
+
+	// Eventos gerados sob o write lock, entregues aos watchers depois dele
+	watchers atomic.Int32
+	pending  []StoreEvent[K, V]
+	batchSeq uint64
+	hub      watchHub[K, V]
+
+	metrics storeMetrics
+
+	// Acompanha, sob o write lock, cada entrada gravada ou removida
+	accounting storeAccounting[K, V]
+
+	janitorMu   sync.Mutex
+	janitorStop chan struct{}
+	janitorDone chan struct{}
+}
+
+// StoreConfig holds the construction-time settings of a Store.
+type StoreConfig[K comparable, V any] struct {
+	// MaxEntries bounds the number of entries kept in the store.
+	MaxEntries int
+	// MaxBytes bounds the sum of Sizer(key, value) over all entries.
+	MaxBytes int
+	// Sizer measures an entry for MaxBytes. Strings and byte slices are
+	// measured by length when it is nil; other types count as zero.
+	Sizer func(K, V) int
+	// Policy selects which key is dropped when a limit is hit.
+	// Defaults to LRU when a limit is set without a policy.
+	Policy EvictionPolicy[K]
+	// OnEvict is called for entries dropped to respect the limits.
+	// It runs after the store lock has been released.
+	OnEvict func(K, V)
+	// TrackLatency turns on the latency histograms of Stats; see
+	// SetLatencyTracking.
+	TrackLatency bool
+}
+
+func NewStore[K comparable, V any]() *Store[K, V] {
+	return NewStoreWithConfig(StoreConfig[K, V]{})
+}
+
+func NewStoreWithConfig[K comparable, V any](cfg StoreConfig[K, V]) *Store[K, V] {
+	s := &Store[K, V]{}
+	s.init(cfg)
+	return s
+}
+
+func (s *Store[K, V]) init(cfg StoreConfig[K, V]) {
+	s.data = make(map[K]V)
+	s.maxEntries = cfg.MaxEntries
+	s.maxBytes = cfg.MaxBytes
+	s.sizer = cfg.Sizer
+	s.policy = cfg.Policy
+	s.onEvict = cfg.OnEvict
+	s.metrics.timed.Store(cfg.TrackLatency)
+	if s.policy == nil && (s.maxEntries > 0 || s.maxBytes > 0) {
+		s.policy = NewLRUPolicy[K]()
+	}
+}
+
+type storeType struct {
+	key, value reflect.Type
+}
+
+var typedStores sync.Map // storeType -> *Store[K, V]
+
+// GetStore returns the process-wide Store for the K/V pair, creating it on
+// first use. It is the generic counterpart of GetInstance_example_3.
+func GetStore[K comparable, V any]() *Store[K, V] {
+	t := storeType{reflect.TypeFor[K](), reflect.TypeFor[V]()}
+	if s, ok := typedStores.Load(t); ok {
+		return s.(*Store[K, V])
+	}
+	s, _ := typedStores.LoadOrStore(t, NewStore[K, V]())
+	return s.(*Store[K, V])
+}
+
+// Métodos do Store - THREAD SAFE
+func (s *Store[K, V]) Set(key K, value V) {
+	s.setWithDeadline(key, value, time.Time{})
+}
+
+// SetWithTTL stores value under key and expires it once ttl has elapsed.
+// A non-positive ttl behaves like Set.
+func (s *Store[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	if ttl <= 0 {
+		s.Set(key, value)
+		return
+	}
+	s.setWithDeadline(key, value, time.Now().Add(ttl))
+}
+
+func (s *Store[K, V]) setWithDeadline(key K, value V, deadline time.Time) {
+	defer s.metrics.observe(opSet, s.metrics.start())
+
+	s.mutex.Lock()
+	evicted := s.makeRoomLocked(key, value)
+	s.storeLocked(key, value, deadline)
+	evicted = append(evicted, s.evictOversizedLocked(key)...)
+	s.unlockAndNotify()
+
+	s.notifyEvicted(evicted)
+}
+
+// Get returns the value for key. Expired keys are removed lazily here.
+func (s *Store[K, V]) Get(key K) (V, bool) {
+	start := s.metrics.start()
+	value, ok := s.get(key)
+	s.metrics.observe(opGet, start)
+	if ok {
+		s.metrics.hits.Add(1)
+	} else {
+		s.metrics.misses.Add(1)
+	}
+	return value, ok
+}
+
+func (s *Store[K, V]) get(key K) (V, bool) {
+	s.mutex.RLock()
+	if s.data == nil {
+		s.mutex.RUnlock()
+		var zero V
+		return zero, false
+	}
+	value, exists := s.data[key]
+	deadline, hasTTL := s.expires[key]
+	if exists && s.policy != nil {
+		s.policy.Accessed(key)
+	}
+	s.mutex.RUnlock()
+
+	if !exists || !hasTTL || time.Now().Before(deadline) {
+		return value, exists
+	}
+
+	// Expirou: troca para o write lock e confirma antes de remover
+	s.mutex.Lock()
+	defer s.unlockAndNotify()
+	if d, ok := s.expires[key]; ok && !time.Now().Before(d) {
+		s.removeLocked(key, EventExpire)
+		var zero V
+		return zero, false
+	}
+	value, exists = s.data[key]
+	return value, exists
+}
+
+func (s *Store[K, V]) Delete(key K) {
+	defer s.metrics.observe(opDelete, s.metrics.start())
+
+	s.mutex.Lock()
+	defer s.unlockAndNotify()
+
+	s.removeLocked(key, EventDelete)
+}
+
+// TTL returns the time left before key expires. ok is false when the key
+// does not exist; a zero duration means it never expires.
+func (s *Store[K, V]) TTL(key K) (ttl time.Duration, ok bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if _, exists := s.data[key]; !exists {
+		return 0, false
+	}
+	deadline, hasTTL := s.expires[key]
+	if !hasTTL {
+		return 0, true
+	}
+	left := time.Until(deadline)
+	if left <= 0 {
+		return 0, false
+	}
+	return left, true
+}
+
+// Size reports the number of live (non-expired) entries.
+func (s *Store[K, V]) Size() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if s.data == nil {
+		return 0
+	}
+	now := time.Now()
+	expired := 0
+	for _, deadline := range s.expires {
+		if !now.Before(deadline) {
+			expired++
+		}
+	}
+	return len(s.data) - expired
+}
+
+// storeLocked writes the entry and keeps the byte count and eviction
+// policy in sync. A zero deadline means the entry never expires.
+// Caller holds s.mutex.
+func (s *Store[K, V]) storeLocked(key K, value V, deadline time.Time) {
+	if s.data == nil {
+		s.data = make(map[K]V)
+	}
+	old, hadOld := s.data[key]
+	if hadOld {
+		s.bytes -= s.sizeOf(key, old)
+	}
+	s.data[key] = value
+	s.bytes += s.sizeOf(key, value)
+
+	if deadline.IsZero() {
+		delete(s.expires, key)
+	} else {
+		if s.expires == nil {
+			s.expires = make(map[K]time.Time)
+		}
+		s.expires[key] = deadline
+	}
+	if s.policy != nil {
+		s.policy.Added(key)
+	}
+	s.metrics.sets.Add(1)
+	if s.accounting != nil {
+		s.accounting.stored(key, old, hadOld, value)
+	}
+	s.recordLocked(StoreEvent[K, V]{Kind: EventSet, Key: key, OldValue: old, HadOld: hadOld, NewValue: value})
+}
+
+// removeLocked deletes key and reports whether it was present. kind tells
+// watchers why the key went away. Caller holds s.mutex.
+func (s *Store[K, V]) removeLocked(key K, kind EventKind) (V, bool) {
+	value, ok := s.data[key]
+	if !ok {
+		return value, false
+	}
+	delete(s.data, key)
+	delete(s.expires, key)
+	s.bytes -= s.sizeOf(key, value)
+	if s.policy != nil {
+		s.policy.Removed(key)
+	}
+	s.metrics.removed(kind)
+	if s.accounting != nil {
+		s.accounting.removed(key, value, kind)
+	}
+	s.recordLocked(StoreEvent[K, V]{Kind: kind, Key: key, OldValue: value, HadOld: true})
+	return value, true
+}
+
+// storeAccounting is told about every entry written or removed while the
+// store lock is held, so usage can be tracked per group of keys without
+// scanning the store.
+type storeAccounting[K comparable, V any] interface {
+	stored(key K, old V, hadOld bool, value V)
+	removed(key K, value V, kind EventKind)
+}
+
+type evictedEntry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// makeRoomLocked evicts entries so that key=value fits within the limits
+// before it is written. Expired keys go first so live data is only evicted
+// when really needed. When key itself is the next victim it is skipped,
+// since overwriting it already frees its old value; with the built-in
+// policies it also keeps its place, so a FIFO overwrite does not make the
+// key young again. An entry larger than the whole budget is evicted right
+// after being written. Caller holds s.mutex.
+func (s *Store[K, V]) makeRoomLocked(key K, value V) []evictedEntry[K, V] {
+	if s.maxEntries <= 0 && s.maxBytes <= 0 {
+		return nil
+	}
+	extraEntries, extraBytes := 1, s.sizeOf(key, value)
+	if old, ok := s.data[key]; ok {
+		extraEntries, extraBytes = 0, s.sizeOf(key, value)-s.sizeOf(key, old)
+	}
+	if !s.overCapacityLocked(extraEntries, extraBytes) {
+		return nil
+	}
+	s.deleteExpiredLocked()
+
+	skipper, canSkip := s.policy.(victimSkipper[K])
+	var evicted []evictedEntry[K, V]
+	for s.overCapacityLocked(extraEntries, extraBytes) {
+		var victim K
+		var ok bool
+		if canSkip {
+			victim, ok = skipper.victimExcept(key)
+		} else {
+			victim, ok = s.policy.Victim()
+		}
+		if !ok {
+			break
+		}
+		if victim == key {
+			// O valor antigo já sai com a escrita e está descontado em
+			// extraBytes. Uma política externa não sabe pular a chave:
+			// ela esquece a chave, que volta como nova em storeLocked
+			s.policy.Removed(key)
+			continue
+		}
+		value, _ := s.removeLocked(victim, EventEvict)
+		evicted = append(evicted, evictedEntry[K, V]{victim, value})
+	}
+	return evicted
+}
+
+// evictOversizedLocked drops key if it still does not fit on its own.
+// Caller holds s.mutex.
+func (s *Store[K, V]) evictOversizedLocked(key K) []evictedEntry[K, V] {
+	if !s.overCapacityLocked(0, 0) {
+		return nil
+	}
+	value, ok := s.removeLocked(key, EventEvict)
+	if !ok {
+		return nil
+	}
+	return []evictedEntry[K, V]{{key, value}}
+}
+
+func (s *Store[K, V]) overCapacityLocked(extraEntries, extraBytes int) bool {
+	return (s.maxEntries > 0 && len(s.data)+extraEntries > s.maxEntries) ||
+		(s.maxBytes > 0 && s.bytes+extraBytes > s.maxBytes)
+}
+
+func (s *Store[K, V]) notifyEvicted(evicted []evictedEntry[K, V]) {
+	if s.onEvict == nil {
+		return
+	}
+	for _, e := range evicted {
+		s.onEvict(e.key, e.value)
+	}
+}
+
+// deleteExpired removes every expired key and returns how many were removed.
+func (s *Store[K, V]) deleteExpired() int {
+	s.mutex.Lock()
+	defer s.unlockAndNotify()
+
+	return s.deleteExpiredLocked()
+}
+
+func (s *Store[K, V]) deleteExpiredLocked() int {
+	now := time.Now()
+	removed := 0
+	for key, deadline := range s.expires {
+		if !now.Before(deadline) {
+			s.removeLocked(key, EventExpire)
+			removed++
+		}
+	}
+	return removed
+}
+
+// StartJanitor launches a background goroutine that sweeps expired keys
+// every interval. Calling it while a janitor is running is a no-op.
+func (s *Store[K, V]) StartJanitor(interval time.Duration) {
+	s.janitorMu.Lock()
+	defer s.janitorMu.Unlock()
+
+	if s.janitorStop != nil || interval <= 0 {
+		return
+	}
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	s.janitorStop, s.janitorDone = stop, done
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.deleteExpired()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// StopJanitor stops the janitor goroutine and waits for it to exit.
+func (s *Store[K, V]) StopJanitor() {
+	s.janitorMu.Lock()
+	defer s.janitorMu.Unlock()
+
+	if s.janitorStop == nil {
+		return
+	}
+	close(s.janitorStop)
+	<-s.janitorDone
+	s.janitorStop, s.janitorDone = nil, nil
+}
+
+// storeEntry is a copy of one entry, used to export and import the
+// store contents. A zero Deadline means the entry never expires.
+type storeEntry[K comparable, V any] struct {
+	Key      K
+	Value    V
+	Deadline time.Time
+}
+
+// entries returns a copy of every live entry.
+func (s *Store[K, V]) entries() []storeEntry[K, V] {
+	return s.entriesWhere(nil)
+}
+
+// entriesWhere is entries restricted to the keys keep accepts; a nil keep
+// accepts every key.
+func (s *Store[K, V]) entriesWhere(keep func(K) bool) []storeEntry[K, V] {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	now := time.Now()
+	var out []storeEntry[K, V]
+	if keep == nil {
+		out = make([]storeEntry[K, V], 0, len(s.data))
+	}
+	for key, value := range s.data {
+		if keep != nil && !keep(key) {
+			continue
+		}
+		deadline, hasTTL := s.expires[key]
+		if hasTTL && !now.Before(deadline) {
+			continue
+		}
+		out = append(out, storeEntry[K, V]{Key: key, Value: value, Deadline: deadline})
+	}
+	return out
+}
+
+// replace swaps the whole contents of the store for entries, skipping
+// the ones that already expired. Capacity limits still apply.
+func (s *Store[K, V]) replace(entries []storeEntry[K, V]) {
+	s.mutex.Lock()
+	for key := range s.data {
+		s.removeLocked(key, EventDelete)
+	}
+	now := time.Now()
+	var evicted []evictedEntry[K, V]
+	for _, e := range entries {
+		if !e.Deadline.IsZero() && !now.Before(e.Deadline) {
+			continue
+		}
+		evicted = append(evicted, s.makeRoomLocked(e.Key, e.Value)...)
+		s.storeLocked(e.Key, e.Value, e.Deadline)
+		evicted = append(evicted, s.evictOversizedLocked(e.Key)...)
+	}
+	s.unlockAndNotify()
+
+	s.notifyEvicted(evicted)
+}
+
+// sizeOf measures an entry for the byte budget.
+func (s *Store[K, V]) sizeOf(key K, value V) int {
+	if s.sizer != nil {
+		return s.sizer(key, value)
+	}
+	return lenOf(key) + lenOf(value)
+}
+
+func lenOf(v any) int {
+	switch v := v.(type) {
+	case string:
+		return len(v)
+	case []byte:
+		return len(v)
+	}
+	return 0
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// rawLen counts the entries held by s, expired or not.
+func rawLen(s *singleton) int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return len(s.data)
+}
+
+func TestTTLExpiresOnRead(t *testing.T) {
+	s := newSingleton()
+	s.SetWithTTL("k", "v", 20*time.Millisecond)
+	if v, ok := s.Get("k"); !ok || v != "v" {
+		t.Fatalf("Get before expiry = %q, %t", v, ok)
+	}
+	if ttl, ok := s.TTL("k"); !ok || ttl <= 0 || ttl > 20*time.Millisecond {
+		t.Errorf("TTL = %v, %t", ttl, ok)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	// Expirada mas ainda não lida: já não aparece, mas ocupa o map
+	if s.Size() != 0 || rawLen(s) != 1 {
+		t.Errorf("size %d, raw %d; want 0 live and 1 held", s.Size(), rawLen(s))
+	}
+	if _, ok := s.TTL("k"); ok {
+		t.Error("TTL reports an expired key")
+	}
+	if _, ok := s.Get("k"); ok {
+		t.Error("Get returned an expired key")
+	}
+	if rawLen(s) != 0 || s.Stats().Expirations != 1 {
+		t.Errorf("raw %d, expirations %d after the read; want the key removed", rawLen(s), s.Stats().Expirations)
+	}
+}
+
+func TestTTLOverwrite(t *testing.T) {
+	s := newSingleton()
+	s.SetWithTTL("k", "1", time.Hour)
+	s.Set("k", "2") // Set sem TTL tira o TTL anterior
+	if ttl, ok := s.TTL("k"); !ok || ttl != 0 {
+		t.Errorf("TTL after Set = %v, %t; want none", ttl, ok)
+	}
+
+	s.SetWithTTL("k", "3", 10*time.Millisecond)
+	s.SetWithTTL("k", "4", time.Hour) // O TTL novo substitui o antigo
+	time.Sleep(20 * time.Millisecond)
+	if v, ok := s.Get("k"); !ok || v != "4" {
+		t.Errorf("k = %q, %t; want the overwrite to reset the TTL", v, ok)
+	}
+
+	s.SetWithTTL("k", "5", 0)
+	if ttl, ok := s.TTL("k"); !ok || ttl != 0 {
+		t.Errorf("TTL after SetWithTTL(0) = %v, %t; want none", ttl, ok)
+	}
+}
+
+func TestJanitor(t *testing.T) {
+	s := newSingleton()
+	s.StartJanitor(5 * time.Millisecond)
+	s.StartJanitor(time.Hour) // Já rodando: não faz nada
+	for _, key := range []string{"a", "b", "c"} {
+		s.SetWithTTL(key, "v", time.Millisecond)
+	}
+	s.Set("fixa", "v")
+
+	// Sem nenhuma leitura, só o janitor remove as chaves
+	deadline := time.Now().Add(5 * time.Second)
+	for rawLen(s) != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("janitor left %d entries", rawLen(s))
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if n := s.Stats().Expirations; n != 3 {
+		t.Errorf("expirations = %d, want 3", n)
+	}
+
+	s.StopJanitor()
+	s.StopJanitor() // Idempotente
+	s.SetWithTTL("d", "v", time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	if rawLen(s) != 2 {
+		t.Errorf("raw %d after StopJanitor, want the expired key kept", rawLen(s))
+	}
+}
+
+func TestEvictionOverwriteOfVictim(t *testing.T) {
+	policies := map[string]func() EvictionPolicy[string]{
+		"lru":  NewLRUPolicy[string],
+		"fifo": NewFIFOPolicy[string],
+		"lfu":  NewLFUPolicy[string],
+	}
+	for name, policy := range policies {
+		t.Run(name, func(t *testing.T) {
+			var evicted []string
+			s := newSingleton(WithMaxBytes(10), WithEvictionPolicy(policy()),
+				WithOnEvict(func(k, v string) { evicted = append(evicted, k+"="+v) }))
+			s.Set("a", "12")   // 3 bytes
+			s.Set("b", "1234") // 5 bytes
+			s.Get("b")
+			// a é a vítima em todas as políticas, mas está sendo sobrescrita:
+			// quem sai é b, e o valor novo de a fica
+			s.Set("a", "12345")
+
+			if v, ok := s.Get("a"); !ok || v != "12345" {
+				t.Errorf("a = %q, %t; want the value just written", v, ok)
+			}
+			if _, ok := s.Get("b"); ok {
+				t.Error("b was kept over the limit")
+			}
+			if len(evicted) != 1 || evicted[0] != "b=1234" {
+				t.Errorf("evicted %v, want [b=1234]", evicted)
+			}
+		})
+	}
+}
+
+func TestEvictionFIFOOverwriteKeepsPosition(t *testing.T) {
+	var evicted []string
+	s := newSingleton(WithMaxBytes(12), WithEvictionPolicy(NewFIFOPolicy[string]()),
+		WithOnEvict(func(k, v string) { evicted = append(evicted, k) }))
+	s.Set("a", "1")       // 2 bytes
+	s.Set("b", "12")      // 3 bytes
+	s.Set("c", "1")       // 2 bytes
+	s.Set("a", "1234567") // 8 bytes: b sai, a continua a mais antiga
+	s.Set("d", "1")       // 2 bytes, cabe
+	s.Set("e", "1")       // 2 bytes: sai a, não c
+
+	if len(evicted) != 2 || evicted[0] != "b" || evicted[1] != "a" {
+		t.Errorf("evicted %v, want [b a]", evicted)
+	}
+	if _, ok := s.Get("c"); !ok {
+		t.Error("c was evicted before the older a")
+	}
+}
+
+func TestEvictionOversizedValue(t *testing.T) {
+	var evicted []string
+	s := newSingleton(WithMaxBytes(10), WithOnEvict(func(k, v string) { evicted = append(evicted, k) }))
+	s.Set("a", "1")
+	s.Set("big", "0123456789")
+	if _, ok := s.Get("big"); ok {
+		t.Error("value larger than the budget was kept")
+	}
+	if len(evicted) != 2 {
+		t.Errorf("evicted %v, want a and big", evicted)
+	}
+}
+
+type point struct{ X, Y int }
+
+func TestGenericStore(t *testing.T) {
+	s := NewStore[point, []int]()
+	s.Set(point{1, 2}, []int{3})
+	s.SetWithTTL(point{0, 0}, nil, time.Hour)
+	if v, ok := s.Get(point{1, 2}); !ok || len(v) != 1 || v[0] != 3 {
+		t.Errorf("Get(1,2) = %v, %t", v, ok)
+	}
+	if v, ok := s.Get(point{0, 0}); !ok || v != nil {
+		t.Errorf("Get(0,0) = %v, %t; want a stored nil", v, ok)
+	}
+	if _, ok := s.Get(point{2, 1}); ok {
+		t.Error("Get of a missing key reported a hit")
+	}
+	s.Delete(point{1, 2})
+	if s.Size() != 1 {
+		t.Errorf("Size = %d, want 1", s.Size())
+	}
+
+	// Sizer mede tipos que o store não conhece
+	var evicted []int
+	counts := NewStoreWithConfig(StoreConfig[int, point]{
+		MaxBytes: 3,
+		Sizer:    func(k int, p point) int { return 1 + p.X },
+		OnEvict:  func(k int, p point) { evicted = append(evicted, k) },
+	})
+	counts.Set(1, point{X: 1}) // 2
+	counts.Set(2, point{})     // 1
+	counts.Set(3, point{})     // 1: passa de 3, sai a chave 1
+	if len(evicted) != 1 || evicted[0] != 1 || counts.Size() != 2 {
+		t.Errorf("evicted %v, size %d", evicted, counts.Size())
+	}
+}
+
+func TestGetStore(t *testing.T) {
+	withFreshInstance(t)
+
+	type userID int
+	ints := GetStore[int, string]()
+	ints.Set(7, "sete")
+	if GetStore[int, string]() != ints {
+		t.Fatal("GetStore returned a new store for the same types")
+	}
+	if v, _ := GetStore[int, string]().Get(7); v != "sete" {
+		t.Errorf("Get(7) = %q", v)
+	}
+	// Cada par K/V tem o seu store, mesmo com o mesmo tipo subjacente
+	if any(GetStore[string, int]()) == any(ints) || any(GetStore[userID, string]()) == any(ints) {
+		t.Error("different K/V pairs share a store")
+	}
+	if GetStore[userID, string]().Size() != 0 {
+		t.Error("store of userID sees the keys of int")
+	}
+
+	var wg sync.WaitGroup
+	got := make([]*Store[point, bool], 50)
+	for i := range got {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got[i] = GetStore[point, bool]()
+		}()
+	}
+	wg.Wait()
+	for _, s := range got {
+		if s != got[0] {
+			t.Fatal("concurrent first calls got different stores")
+		}
+	}
+}
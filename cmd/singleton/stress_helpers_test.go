@@ -0,0 +1,392 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+type callKind uint8
+
+const (
+	callSet callKind = iota
+	callGet
+	callDelete
+	callSize
+)
+
+func (k callKind) String() string {
+	switch k {
+	case callSet:
+		return "Set"
+	case callGet:
+		return "Get"
+	case callDelete:
+		return "Delete"
+	case callSize:
+		return "Size"
+	}
+	return fmt.Sprintf("callKind(%d)", uint8(k))
+}
+
+// operation is one completed call in a stress history. Call and Return are
+// ticks of a shared logical clock: a returned before b was called exactly
+// when a.Return < b.Call.
+type operation struct {
+	Client       int
+	Kind         callKind
+	Key          string
+	Value        string // Argumento do Set ou resultado do Get
+	Found        bool   // Resultado do Get
+	Size         int    // Resultado do Size
+	Call, Return int64
+	// Pending marks a write cut off by minimizeCounterexample: it may or
+	// may not have taken effect.
+	Pending bool
+}
+
+func (op operation) String() string {
+	var desc string
+	switch op.Kind {
+	case callSet:
+		desc = fmt.Sprintf("Set(%q, %q)", op.Key, op.Value)
+	case callGet:
+		desc = fmt.Sprintf("Get(%q) -> %q, %t", op.Key, op.Value, op.Found)
+	case callDelete:
+		desc = fmt.Sprintf("Delete(%q)", op.Key)
+	case callSize:
+		desc = fmt.Sprintf("Size() -> %d", op.Size)
+	}
+	if op.Pending {
+		return fmt.Sprintf("client %d [%d, ...] %s (pending)", op.Client, op.Call, desc)
+	}
+	return fmt.Sprintf("client %d [%d, %d] %s", op.Client, op.Call, op.Return, desc)
+}
+
+// stressConfig shapes a runStress workload. Few keys make operations on
+// the same key overlap often, which is where bugs show up.
+type stressConfig struct {
+	Goroutines int
+	Ops        int // Operações por goroutine
+	Keys       int
+	NoSize     bool // Omite Size, para stores cujo Size não é atômico
+	Seed       uint64
+}
+
+var defaultStressConfig = stressConfig{Goroutines: 8, Ops: 200, Keys: 4, Seed: 1}
+
+// runStress runs cfg.Goroutines goroutines issuing random Set, Get,
+// Delete and Size calls and returns the recorded history. get is called
+// before every operation, so a GetInstance function that hands out
+// different instances shows up as lost writes.
+func runStress(tb testing.TB, get func() kvStore, cfg stressConfig) []operation {
+	tb.Helper()
+	var clock atomic.Int64
+	histories := make([][]operation, cfg.Goroutines)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for c := range cfg.Goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rng := rand.New(rand.NewPCG(cfg.Seed, uint64(c)))
+			kinds := 4
+			if cfg.NoSize {
+				kinds = 3
+			}
+			ops := make([]operation, 0, cfg.Ops)
+			<-start
+			for i := range cfg.Ops {
+				op := operation{
+					Client: c,
+					Kind:   callKind(rng.IntN(kinds)),
+					Key:    fmt.Sprintf("k%d", rng.IntN(cfg.Keys)),
+				}
+				s := get()
+				op.Call = clock.Add(1)
+				switch op.Kind {
+				case callSet:
+					op.Value = fmt.Sprintf("v%d.%d", c, i)
+					s.Set(op.Key, op.Value)
+				case callGet:
+					op.Value, op.Found = s.Get(op.Key)
+				case callDelete:
+					s.Delete(op.Key)
+				case callSize:
+					op.Key = ""
+					op.Size = s.Size()
+				}
+				op.Return = clock.Add(1)
+				ops = append(ops, op)
+			}
+			histories[c] = ops
+		}()
+	}
+	close(start)
+	wg.Wait()
+	return slices.Concat(histories...)
+}
+
+// requireLinearizable fails tb with a minimal counterexample when history
+// has no valid linearization.
+func requireLinearizable(tb testing.TB, history []operation) {
+	tb.Helper()
+	if checkLinearizable(history) {
+		return
+	}
+	bad := minimizeCounterexample(history)
+	tb.Fatalf("history of %d operations is not linearizable; minimal counterexample (%d operations):\n%s",
+		len(history), len(bad), formatHistory(bad))
+}
+
+func formatHistory(history []operation) string {
+	sorted := slices.Clone(history)
+	slices.SortFunc(sorted, func(a, b operation) int { return int(a.Call - b.Call) })
+	var b strings.Builder
+	for _, op := range sorted {
+		fmt.Fprintf(&b, "\t%s\n", op)
+	}
+	return b.String()
+}
+
+// checkLinearizable reports whether history can be ordered so that each
+// operation takes effect at a single instant between its call and its
+// return, with every result matching a sequential map. It searches the
+// interleavings of the per-client sequences depth-first, memoizing the
+// (progress, map state) pairs already known to be dead ends. A pending
+// operation, always the last of its client, may also be left out.
+func checkLinearizable(history []operation) bool {
+	var clients [][]operation
+	byClient := make(map[int]int)
+	for _, op := range history {
+		i, ok := byClient[op.Client]
+		if !ok {
+			i = len(clients)
+			byClient[op.Client] = i
+			clients = append(clients, nil)
+		}
+		clients[i] = append(clients[i], op)
+	}
+	for _, ops := range clients {
+		slices.SortFunc(ops, func(a, b operation) int { return int(a.Call - b.Call) })
+	}
+
+	next := make([]int, len(clients))
+	dead := make(map[string]bool)
+	var search func(state map[string]string) bool
+	search = func(state map[string]string) bool {
+		done := true
+		for c, ops := range clients {
+			if left := ops[next[c]:]; len(left) > 1 || len(left) == 1 && !left[0].Pending {
+				done = false
+				break
+			}
+		}
+		if done {
+			return true
+		}
+		key := searchKey(next, state)
+		if dead[key] {
+			return false
+		}
+		for c, ops := range clients {
+			if next[c] == len(ops) {
+				continue
+			}
+			op := ops[next[c]]
+			if !minimal(clients, next, op) {
+				continue
+			}
+			after, ok := applyOp(state, op)
+			if !ok {
+				continue
+			}
+			next[c]++
+			found := search(after)
+			next[c]--
+			if found {
+				return true
+			}
+		}
+		dead[key] = true
+		return false
+	}
+	return search(map[string]string{})
+}
+
+// minimal reports whether op may be linearized before every operation
+// still pending, i.e. none of them returned before op was called. Within
+// a client calls and returns increase, so only the head of each client
+// needs checking.
+func minimal(clients [][]operation, next []int, op operation) bool {
+	for c, ops := range clients {
+		if next[c] < len(ops) && ops[next[c]].Return < op.Call {
+			return false
+		}
+	}
+	return true
+}
+
+func applyOp(state map[string]string, op operation) (map[string]string, bool) {
+	switch op.Kind {
+	case callSet:
+		after := make(map[string]string, len(state)+1)
+		for k, v := range state {
+			after[k] = v
+		}
+		after[op.Key] = op.Value
+		return after, true
+	case callDelete:
+		if _, ok := state[op.Key]; !ok {
+			return state, true
+		}
+		after := make(map[string]string, len(state))
+		for k, v := range state {
+			if k != op.Key {
+				after[k] = v
+			}
+		}
+		return after, true
+	case callGet:
+		v, ok := state[op.Key]
+		return state, ok == op.Found && v == op.Value
+	case callSize:
+		return state, len(state) == op.Size
+	}
+	return state, false
+}
+
+func searchKey(next []int, state map[string]string) string {
+	var b strings.Builder
+	for _, n := range next {
+		fmt.Fprintf(&b, "%d,", n)
+	}
+	b.WriteByte('|')
+	keys := make([]string, 0, len(state))
+	for k := range state {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&b, "%q=%q;", k, state[k])
+	}
+	return b.String()
+}
+
+// minimizeCounterexample shrinks a non-linearizable history using only
+// reductions that keep it a genuine counterexample, i.e. that cannot
+// turn a linearizable history into a non-linearizable one:
+//
+//   - cutting the history at the earliest tick where it already fails;
+//     operations still running at the cut become pending writes or are
+//     dropped if they are reads;
+//   - dropping Get and Size, which never change the state;
+//   - without Size, keeping only the operations on one key, as keys do
+//     not constrain each other;
+//   - within one key, starting at a write that overlaps no other
+//     operation, since the state right after it is known.
+//
+// Arbitrary writes are never dropped: a Get could then observe a value
+// nobody set and the counterexample would be an artifact of shrinking.
+func minimizeCounterexample(history []operation) []operation {
+	bad := shortestFailingCut(history)
+
+	withoutSize := slices.DeleteFunc(slices.Clone(bad), func(op operation) bool { return op.Kind == callSize })
+	if !checkLinearizable(withoutSize) {
+		bad = withoutSize
+		byKey := make(map[string][]operation)
+		for _, op := range bad {
+			byKey[op.Key] = append(byKey[op.Key], op)
+		}
+		for _, ops := range byKey {
+			if len(ops) < len(bad) && !checkLinearizable(ops) {
+				bad = ops
+			}
+		}
+		bad = trimBeforeQuiescentWrite(bad)
+	}
+
+	for i := 0; i < len(bad); {
+		if bad[i].Kind == callSet || bad[i].Kind == callDelete {
+			i++
+			continue
+		}
+		candidate := slices.Delete(slices.Clone(bad), i, i+1)
+		if checkLinearizable(candidate) {
+			i++
+			continue
+		}
+		bad = candidate
+	}
+	return bad
+}
+
+// shortestFailingCut binary searches the return ticks for the earliest
+// cut of history that is still not linearizable.
+func shortestFailingCut(history []operation) []operation {
+	ticks := make([]int64, 0, len(history))
+	for _, op := range history {
+		ticks = append(ticks, op.Return)
+	}
+	slices.Sort(ticks)
+
+	bad := history
+	lo, hi := 0, len(ticks)-1 // O corte em ticks[hi] é o histórico inteiro
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if cut := cutHistory(history, ticks[mid]); !checkLinearizable(cut) {
+			bad, hi = cut, mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	return bad
+}
+
+// cutHistory keeps what had been called by tick t. Each operation has to
+// take effect between its call and return, so the ones done by t form a
+// valid prefix of any linearization, possibly with some of the running
+// ones in between: those become pending.
+func cutHistory(history []operation, t int64) []operation {
+	var cut []operation
+	for _, op := range history {
+		switch {
+		case op.Call > t:
+		case op.Return <= t:
+			cut = append(cut, op)
+		case op.Kind == callSet || op.Kind == callDelete:
+			op.Pending = true
+			cut = append(cut, op)
+		}
+	}
+	return cut
+}
+
+// trimBeforeQuiescentWrite drops everything before the latest completed
+// write that overlaps no other operation of the single-key history ops,
+// as long as the rest still fails.
+func trimBeforeQuiescentWrite(ops []operation) []operation {
+	sorted := slices.Clone(ops)
+	slices.SortFunc(sorted, func(a, b operation) int { return int(a.Call - b.Call) })
+	for i := len(sorted) - 1; i > 0; i-- {
+		w := sorted[i]
+		if w.Pending || w.Kind != callSet && w.Kind != callDelete {
+			continue
+		}
+		quiet := true
+		for j, o := range sorted {
+			if j != i && o.Call < w.Return && w.Call < o.Return {
+				quiet = false
+				break
+			}
+		}
+		if quiet && !checkLinearizable(sorted[i:]) {
+			return sorted[i:]
+		}
+	}
+	return ops
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStressGetInstanceVariants(t *testing.T) {
+	for _, v := range getInstanceVariants {
+		t.Run(v.name, func(t *testing.T) {
+			withFreshInstance(t)
+			if !v.concurrent {
+				// Exemplo 1 só é seguro depois de inicializado
+				v.get()
+			}
+
+			history := runStress(t, func() kvStore { return v.get() }, defaultStressConfig)
+			requireLinearizable(t, history)
+		})
+	}
+}
+
+func TestStressStore(t *testing.T) {
+	stores := []struct {
+		name string
+		new  func() kvStore
+		cfg  stressConfig
+	}{
+		{"store", func() kvStore { return NewStore[string, string]() }, defaultStressConfig},
+		{"singleton", func() kvStore { return newSingleton() }, defaultStressConfig},
+		// Size de ShardedStore soma os shards um a um e pode ver um estado
+		// que nunca existiu; só Set/Get/Delete são linearizáveis
+		{"sharded", func() kvStore { return newShardedSingleton(4) }, stressConfig{Goroutines: 8, Ops: 200, Keys: 4, NoSize: true, Seed: 1}},
+	}
+	for _, st := range stores {
+		t.Run(st.name, func(t *testing.T) {
+			t.Parallel()
+			s := st.new()
+			requireLinearizable(t, runStress(t, func() kvStore { return s }, st.cfg))
+		})
+	}
+}
+
+func TestCheckLinearizable(t *testing.T) {
+	tests := []struct {
+		name    string
+		history []operation
+		want    bool
+	}{
+		{
+			name: "overlapping get may see the new value",
+			history: []operation{
+				{Client: 0, Kind: callSet, Key: "a", Value: "1", Call: 1, Return: 4},
+				{Client: 1, Kind: callGet, Key: "a", Value: "1", Found: true, Call: 2, Return: 3},
+			},
+			want: true,
+		},
+		{
+			name: "overlapping get may miss the new value",
+			history: []operation{
+				{Client: 0, Kind: callSet, Key: "a", Value: "1", Call: 1, Return: 4},
+				{Client: 1, Kind: callGet, Key: "a", Call: 2, Return: 3},
+			},
+			want: true,
+		},
+		{
+			name: "get after set returned must see it",
+			history: []operation{
+				{Client: 0, Kind: callSet, Key: "a", Value: "1", Call: 1, Return: 2},
+				{Client: 1, Kind: callGet, Key: "a", Call: 3, Return: 4},
+			},
+			want: false,
+		},
+		{
+			name: "size counts live keys",
+			history: []operation{
+				{Client: 0, Kind: callSet, Key: "a", Value: "1", Call: 1, Return: 2},
+				{Client: 0, Kind: callSet, Key: "b", Value: "2", Call: 3, Return: 4},
+				{Client: 1, Kind: callDelete, Key: "a", Call: 5, Return: 8},
+				{Client: 0, Kind: callSize, Size: 1, Call: 6, Return: 7},
+			},
+			want: true,
+		},
+		{
+			name: "reads cannot go back in time",
+			history: []operation{
+				{Client: 0, Kind: callSet, Key: "a", Value: "1", Call: 1, Return: 10},
+				{Client: 1, Kind: callGet, Key: "a", Value: "1", Found: true, Call: 2, Return: 3},
+				{Client: 2, Kind: callGet, Key: "a", Call: 4, Return: 5},
+			},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checkLinearizable(tt.history); got != tt.want {
+				t.Fatalf("checkLinearizable() = %t, want %t\n%s", got, tt.want, formatHistory(tt.history))
+			}
+		})
+	}
+}
+
+func TestMinimizeCounterexample(t *testing.T) {
+	history := []operation{
+		{Client: 0, Kind: callSet, Key: "a", Value: "1", Call: 1, Return: 2},
+		{Client: 1, Kind: callSet, Key: "b", Value: "2", Call: 3, Return: 4},
+		{Client: 1, Kind: callGet, Key: "b", Value: "2", Found: true, Call: 5, Return: 6},
+		{Client: 2, Kind: callSize, Size: 2, Call: 7, Return: 8},
+		{Client: 0, Kind: callGet, Key: "a", Value: "1", Found: true, Call: 9, Return: 10},
+		{Client: 2, Kind: callGet, Key: "a", Call: 11, Return: 12}, // a violação
+		{Client: 1, Kind: callDelete, Key: "b", Call: 13, Return: 14},
+	}
+	if checkLinearizable(history) {
+		t.Fatal("history should not be linearizable")
+	}
+
+	got := minimizeCounterexample(history)
+	want := []operation{history[0], history[5]}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("minimizeCounterexample() =\n%swant\n%s", formatHistory(got), formatHistory(want))
+	}
+	if s := formatHistory(got); !strings.Contains(s, `Get("a") -> "", false`) {
+		t.Fatalf("formatted counterexample lacks the offending Get:\n%s", s)
+	}
+}
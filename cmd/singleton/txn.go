@@ -0,0 +1,251 @@
+package main
+
+import (
+	"time"
+)
+
+// storeMutation is one write committed to a store: a Set (with an
+// optional deadline) or a Delete. Transactions return them so callers
+// such as the write-ahead log can record what actually changed.
+type storeMutation[K comparable, V any] struct {
+	Key      K
+	Value    V
+	Deadline time.Time
+	Delete   bool
+}
+
+// StoreTx is the view of a store inside Txn. Reads see the store plus the
+// writes already made in the transaction; writes are buffered and only
+// applied if the transaction function returns nil.
+//
+// A StoreTx runs under the store write lock: it must not be used after
+// the function returns, and the function must not call the store itself.
+type StoreTx[K comparable, V any] struct {
+	store  *Store[K, V]
+	now    time.Time
+	writes []storeMutation[K, V]
+	index  map[K]int // Posição da escrita de cada chave em writes
+}
+
+// Tx is the transaction handle of the string singleton store.
+type Tx = StoreTx[string, string]
+
+func (tx *StoreTx[K, V]) Get(key K) (V, bool) {
+	value, _, ok := tx.lookup(key)
+	return value, ok
+}
+
+func (tx *StoreTx[K, V]) Set(key K, value V) {
+	tx.write(storeMutation[K, V]{Key: key, Value: value})
+}
+
+func (tx *StoreTx[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	if ttl <= 0 {
+		tx.Set(key, value)
+		return
+	}
+	tx.write(storeMutation[K, V]{Key: key, Value: value, Deadline: tx.now.Add(ttl)})
+}
+
+func (tx *StoreTx[K, V]) Delete(key K) {
+	tx.write(storeMutation[K, V]{Key: key, Delete: true})
+}
+
+// lookup returns the live value and deadline of key as seen by the
+// transaction.
+func (tx *StoreTx[K, V]) lookup(key K) (V, time.Time, bool) {
+	if i, ok := tx.index[key]; ok {
+		m := tx.writes[i]
+		return m.Value, m.Deadline, !m.Delete
+	}
+	s := tx.store
+	value, ok := s.data[key]
+	deadline, hasTTL := s.expires[key]
+	if !ok || (hasTTL && !tx.now.Before(deadline)) {
+		var zero V
+		return zero, time.Time{}, false
+	}
+	return value, deadline, true
+}
+
+// write keeps only the last write of each key; the final state is the
+// same and the commit touches every key once.
+func (tx *StoreTx[K, V]) write(m storeMutation[K, V]) {
+	if i, ok := tx.index[m.Key]; ok {
+		tx.writes[i] = m
+		return
+	}
+	if tx.index == nil {
+		tx.index = make(map[K]int)
+	}
+	tx.index[m.Key] = len(tx.writes)
+	tx.writes = append(tx.writes, m)
+}
+
+// Txn runs fn under the write lock and applies its writes atomically when
+// it returns nil. Any error (or panic) discards them.
+func (s *Store[K, V]) Txn(fn func(tx *StoreTx[K, V]) error) error {
+	_, err := s.txn(fn)
+	return err
+}
+
+func (s *Store[K, V]) txn(fn func(tx *StoreTx[K, V]) error) (muts []storeMutation[K, V], err error) {
+	defer s.metrics.observe(opTxn, s.metrics.start())
+
+	var evicted []evictedEntry[K, V]
+	s.mutex.Lock()
+	defer func() {
+		s.unlockAndNotify()
+		s.notifyEvicted(evicted)
+	}()
+
+	tx := &StoreTx[K, V]{store: s, now: time.Now()}
+	if err := fn(tx); err != nil {
+		return nil, err
+	}
+	evicted = s.applyLocked(tx.writes)
+	return tx.writes, nil
+}
+
+// applyLocked commits muts in order. Sets whose deadline already passed
+// are applied as expirations. Caller holds s.mutex.
+func (s *Store[K, V]) applyLocked(muts []storeMutation[K, V]) []evictedEntry[K, V] {
+	now := time.Now()
+	var evicted []evictedEntry[K, V]
+	for _, m := range muts {
+		switch {
+		case m.Delete:
+			s.removeLocked(m.Key, EventDelete)
+		case !m.Deadline.IsZero() && !now.Before(m.Deadline):
+			s.removeLocked(m.Key, EventExpire)
+		default:
+			evicted = append(evicted, s.makeRoomLocked(m.Key, m.Value)...)
+			s.storeLocked(m.Key, m.Value, m.Deadline)
+			evicted = append(evicted, s.evictOversizedLocked(m.Key)...)
+		}
+	}
+	return evicted
+}
+
+// apply commits muts atomically outside of a transaction.
+func (s *Store[K, V]) apply(muts []storeMutation[K, V]) {
+	s.mutex.Lock()
+	evicted := s.applyLocked(muts)
+	s.unlockAndNotify()
+
+	s.notifyEvicted(evicted)
+}
+
+// Update atomically replaces the value of key with the result of fn.
+// fn receives the current value (ok is false when absent) and returns the
+// new value, or false to delete the key. An existing TTL is kept.
+// It returns the value left in the store.
+func (s *Store[K, V]) Update(key K, fn func(old V, ok bool) (V, bool)) (V, bool) {
+	return updateVia(s.Txn, key, fn)
+}
+
+// GetOrSet returns the current value of key if present (loaded is true);
+// otherwise it stores value and returns it.
+func (s *Store[K, V]) GetOrSet(key K, value V) (actual V, loaded bool) {
+	return getOrSetVia(s.Txn, key, value)
+}
+
+// Expire sets a new TTL on an existing key and reports whether the key
+// exists. A non-positive ttl deletes the key right away.
+func (s *Store[K, V]) Expire(key K, ttl time.Duration) bool {
+	return expireVia(s.Txn, key, ttl)
+}
+
+// updateVia, getOrSetVia and expireVia are shared by Store and by wrappers that
+// provide their own Txn, such as the logged singleton.
+func updateVia[K comparable, V any](txn func(func(*StoreTx[K, V]) error) error, key K, fn func(V, bool) (V, bool)) (result V, present bool) {
+	txn(func(tx *StoreTx[K, V]) error {
+		old, deadline, ok := tx.lookup(key)
+		value, keep := fn(old, ok)
+		switch {
+		case keep:
+			tx.write(storeMutation[K, V]{Key: key, Value: value, Deadline: deadline})
+			result, present = value, true
+		case ok:
+			tx.Delete(key)
+		}
+		return nil
+	})
+	return result, present
+}
+
+func getOrSetVia[K comparable, V any](txn func(func(*StoreTx[K, V]) error) error, key K, value V) (actual V, loaded bool) {
+	txn(func(tx *StoreTx[K, V]) error {
+		if current, ok := tx.Get(key); ok {
+			actual, loaded = current, true
+			return nil
+		}
+		tx.Set(key, value)
+		actual = value
+		return nil
+	})
+	return actual, loaded
+}
+
+func expireVia[K comparable, V any](txn func(func(*StoreTx[K, V]) error) error, key K, ttl time.Duration) (exists bool) {
+	txn(func(tx *StoreTx[K, V]) error {
+		value, ok := tx.Get(key)
+		if !ok {
+			return nil
+		}
+		exists = true
+		if ttl <= 0 {
+			tx.Delete(key)
+			return nil
+		}
+		tx.SetWithTTL(key, value, ttl)
+		return nil
+	})
+	return exists
+}
+
+// Txn is the logged version of Store.Txn: the committed writes go to the
+// write-ahead log as one record, so replay never sees half a transaction.
+// A commit that could not be logged returns an error wrapping
+// ErrNotDurable; its writes stay visible in memory.
+func (s *singleton) Txn(fn func(tx *Tx) error) error {
+	w := s.wal.Load()
+	if w == nil {
+		return s.Store.Txn(fn)
+	}
+	var err error
+	walErr := w.apply(func() []storeMutation[string, string] {
+		var muts []storeMutation[string, string]
+		muts, err = s.txn(fn)
+		return muts
+	})
+	if err != nil {
+		return err
+	}
+	return walErr
+}
+
+func (s *singleton) Update(key string, fn func(old string, ok bool) (string, bool)) (string, bool) {
+	return updateVia(s.Txn, key, fn)
+}
+
+func (s *singleton) GetOrSet(key, value string) (actual string, loaded bool) {
+	return getOrSetVia(s.Txn, key, value)
+}
+
+func (s *singleton) Expire(key string, ttl time.Duration) bool {
+	return expireVia(s.Txn, key, ttl)
+}
+
+// CompareAndSwap stores new only if key currently holds old. The key keeps
+// its TTL, as with Update.
+func (s *singleton) CompareAndSwap(key, old, new string) (swapped bool) {
+	s.Txn(func(tx *Tx) error {
+		if current, deadline, ok := tx.lookup(key); ok && current == old {
+			tx.write(storeMutation[string, string]{Key: key, Value: new, Deadline: deadline})
+			swapped = true
+		}
+		return nil
+	})
+	return swapped
+}
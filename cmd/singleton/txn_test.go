@@ -0,0 +1,24 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCompareAndSwapKeepsTTL(t *testing.T) {
+	s := newSingleton()
+	s.SetWithTTL("k", "old", time.Hour)
+
+	if s.CompareAndSwap("k", "other", "new") {
+		t.Fatal("swapped a key holding a different value")
+	}
+	if !s.CompareAndSwap("k", "old", "new") {
+		t.Fatal("did not swap a key holding old")
+	}
+	if v, _ := s.Get("k"); v != "new" {
+		t.Errorf("k = %q, want new", v)
+	}
+	if ttl, ok := s.TTL("k"); !ok || ttl <= 0 || ttl > time.Hour {
+		t.Errorf("TTL after swap = %v, %t; want the hour set before", ttl, ok)
+	}
+}
@@ -0,0 +1,422 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// FsyncPolicy controls when the write-ahead log is flushed to disk.
+type FsyncPolicy int
+
+const (
+	// FsyncAlways syncs after every record: no acknowledged write is lost.
+	FsyncAlways FsyncPolicy = iota
+	// FsyncInterval syncs every DurabilityConfig.FsyncInterval, which must
+	// be positive; a crash can lose the writes of the last interval.
+	FsyncInterval
+	// FsyncNever leaves flushing to the operating system.
+	FsyncNever
+)
+
+// DurabilityConfig describes where a singleton persists its state.
+type DurabilityConfig struct {
+	SnapshotPath   string
+	SnapshotFormat SnapshotFormat
+	WALPath        string
+	Fsync          FsyncPolicy
+	FsyncInterval  time.Duration
+	// CompactThreshold is the log size in bytes that triggers a rewrite
+	// into a fresh snapshot. Zero disables compaction.
+	CompactThreshold int64
+}
+
+// ErrNotDurable is returned once a write to the log has failed. The store
+// keeps working in memory, but later mutations are not logged until a
+// Compact succeeds.
+var ErrNotDurable = errors.New("wal: store is no longer durable")
+
+// maxWALRecord guards replay against allocating a corrupt length.
+const maxWALRecord = 64 << 20
+
+type walOp byte
+
+const (
+	walSet walOp = iota + 1
+	walDelete
+)
+
+// writeAheadLog is an append-only file of records. Each record holds the
+// mutations of one operation and is framed as crc32 (uint32 LE) |
+// length (uvarint) | payload, where the payload is count followed by
+// count × (op | key | value | expiresAt). A torn write at the tail is
+// detected and discarded on replay, and a transaction is never replayed
+// halfway.
+type writeAheadLog struct {
+	mu    sync.Mutex // Serializa apply + append para o log seguir a ordem do store
+	file  walFile
+	size  int64
+	dirty bool
+	err   error // Primeira falha de escrita ou sync; nil enquanto durável
+	cfg   DurabilityConfig
+	owner *singleton
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// walFile is the part of *os.File the log writes through.
+type walFile interface {
+	io.WriteSeeker
+	Truncate(size int64) error
+	Sync() error
+	Close() error
+}
+
+// EnableDurability restores the singleton from cfg.SnapshotPath, replays
+// cfg.WALPath on top of it and from then on records every Set and Delete
+// in the log.
+//
+// The log holds writes only. Evictions are not logged, and neither are
+// the reads that order an LRU or LFU policy, so replay under a capacity
+// limit evicts by the order of the writes and may keep other keys than
+// the store had. Replay is exact only for a store without a capacity
+// limit; Compact after a burst of evictions to make the snapshot match.
+func (s *singleton) EnableDurability(cfg DurabilityConfig) error {
+	if s.wal.Load() != nil {
+		return errors.New("wal: durability already enabled")
+	}
+	if cfg.Fsync == FsyncInterval && cfg.FsyncInterval <= 0 {
+		return fmt.Errorf("wal: fsync interval %v is not positive", cfg.FsyncInterval)
+	}
+	if err := s.LoadSnapshotFile(cfg.SnapshotPath); err != nil {
+		return fmt.Errorf("wal: load snapshot: %w", err)
+	}
+
+	f, err := os.OpenFile(cfg.WALPath, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	size, err := s.replayWAL(f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	// Descarta um registro incompleto no fim, se houver, antes de anexar
+	if err := f.Truncate(size); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Seek(size, io.SeekStart); err != nil {
+		f.Close()
+		return err
+	}
+
+	w := &writeAheadLog{file: f, size: size, cfg: cfg, owner: s}
+	if cfg.Fsync == FsyncInterval {
+		w.stop = make(chan struct{})
+		w.done = make(chan struct{})
+		go w.syncLoop()
+	}
+	s.wal.Store(w)
+	return nil
+}
+
+// DisableDurability flushes and closes the log. Later mutations are kept
+// in memory only.
+func (s *singleton) DisableDurability() error {
+	w := s.wal.Swap(nil)
+	if w == nil {
+		return nil
+	}
+	if w.stop != nil {
+		close(w.stop)
+		<-w.done
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.file.Sync(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
+
+// DurabilityErr reports why the store stopped logging its mutations, as
+// an error wrapping ErrNotDurable, or nil while every acknowledged write
+// is in the log. Set and Delete have no error to return, so this is where
+// their failures show up.
+func (s *singleton) DurabilityErr() error {
+	w := s.wal.Load()
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// Compact rewrites the log into a fresh snapshot and truncates it. It
+// also makes the store durable again after a failed write to the log.
+func (s *singleton) Compact() error {
+	w := s.wal.Load()
+	if w == nil {
+		return errors.New("wal: durability not enabled")
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.compactLocked()
+}
+
+// replayWAL applies every intact record of f and returns the offset just
+// past the last one.
+func (s *singleton) replayWAL(f *os.File) (int64, error) {
+	r := bufio.NewReader(f)
+	var offset int64
+	for {
+		muts, n, err := readWALRecord(r)
+		if errors.Is(err, io.EOF) {
+			return offset, nil
+		}
+		if err != nil {
+			log.Printf("wal: discarding log tail at offset %d: %v", offset, err)
+			return offset, nil
+		}
+		offset += n
+		s.Store.apply(muts)
+	}
+}
+
+// apply runs mutate and appends the mutations it reports while holding
+// the log lock, so the order of records matches the order the store saw.
+// The mutation stays applied in memory either way; if it could not be
+// logged, apply returns an error wrapping ErrNotDurable, and so does every
+// later apply until the store is compacted.
+func (w *writeAheadLog) apply(mutate func() []storeMutation[string, string]) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	muts := mutate()
+	if len(muts) == 0 {
+		return nil
+	}
+	if w.err != nil {
+		// Um registro já faltou no log: anexar os seguintes faria o replay
+		// pular uma mutação e aplicar as de depois
+		return w.err
+	}
+	if err := w.appendLocked(muts); err != nil {
+		w.failLocked(err)
+		return w.err
+	}
+	if w.cfg.CompactThreshold > 0 && w.size >= w.cfg.CompactThreshold {
+		if err := w.compactLocked(); err != nil {
+			log.Printf("wal: compact: %v", err)
+		}
+	}
+	return nil
+}
+
+// appendLocked writes one record. A failed write is cut back to the end
+// of the last good record, so a torn frame never sits in the log.
+func (w *writeAheadLog) appendLocked(muts []storeMutation[string, string]) error {
+	frame := encodeWALRecord(muts)
+	if _, err := w.file.Write(frame); err != nil {
+		if terr := w.file.Truncate(w.size); terr != nil {
+			return errors.Join(err, fmt.Errorf("truncate torn record: %w", terr))
+		}
+		if _, serr := w.file.Seek(w.size, io.SeekStart); serr != nil {
+			return errors.Join(err, serr)
+		}
+		return err
+	}
+	w.size += int64(len(frame))
+	w.dirty = true
+	if w.cfg.Fsync == FsyncAlways {
+		return w.syncLocked()
+	}
+	return nil
+}
+
+// failLocked marks the store not durable, keeping the first cause.
+func (w *writeAheadLog) failLocked(err error) {
+	if w.err == nil {
+		w.err = fmt.Errorf("%w: %w", ErrNotDurable, err)
+		log.Printf("wal: %v", w.err)
+	}
+}
+
+func (w *writeAheadLog) syncLocked() error {
+	if !w.dirty {
+		return nil
+	}
+	if err := w.file.Sync(); err != nil {
+		return err
+	}
+	w.dirty = false
+	return nil
+}
+
+func (w *writeAheadLog) syncLoop() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.cfg.FsyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.mu.Lock()
+			if err := w.syncLocked(); err != nil {
+				w.failLocked(fmt.Errorf("sync: %w", err))
+			}
+			w.mu.Unlock()
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// compactLocked saves a snapshot and only then truncates the log. If the
+// process dies in between, replaying the old log over the new snapshot
+// is harmless: Set and Delete records are idempotent in order.
+func (w *writeAheadLog) compactLocked() error {
+	if err := w.owner.SaveSnapshotFile(w.cfg.SnapshotPath, w.cfg.SnapshotFormat); err != nil {
+		return err
+	}
+	if err := w.file.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := w.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	w.size = 0
+	w.dirty = true
+	if err := w.syncLocked(); err != nil {
+		return err
+	}
+	// O snapshot tem tudo o que o log perdeu
+	w.err = nil
+	return nil
+}
+
+func encodeWALRecord(muts []storeMutation[string, string]) []byte {
+	var payload bytes.Buffer
+	var buf [binary.MaxVarintLen64]byte
+	putUvarint := func(v uint64) { payload.Write(buf[:binary.PutUvarint(buf[:], v)]) }
+	putString := func(v string) {
+		putUvarint(uint64(len(v)))
+		payload.WriteString(v)
+	}
+
+	putUvarint(uint64(len(muts)))
+	for _, m := range muts {
+		op := walSet
+		if m.Delete {
+			op = walDelete
+		}
+		payload.WriteByte(byte(op))
+		putString(m.Key)
+		putString(m.Value)
+		payload.Write(buf[:binary.PutVarint(buf[:], unixNanoOrZero(m.Deadline))])
+	}
+
+	frame := binary.LittleEndian.AppendUint32(nil, crc32.ChecksumIEEE(payload.Bytes()))
+	frame = binary.AppendUvarint(frame, uint64(payload.Len()))
+	return append(frame, payload.Bytes()...)
+}
+
+// readWALRecord returns io.EOF only on a clean end of log; a truncated or
+// corrupt record yields another error.
+func readWALRecord(r *bufio.Reader) ([]storeMutation[string, string], int64, error) {
+	var head [4]byte
+	n, err := io.ReadFull(r, head[:])
+	if n == 0 && errors.Is(err, io.EOF) {
+		return nil, 0, io.EOF
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("read header: %w", io.ErrUnexpectedEOF)
+	}
+	length, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, 0, fmt.Errorf("read length: %w", err)
+	}
+	if length > maxWALRecord {
+		return nil, 0, fmt.Errorf("record length %d too large", length)
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, 0, fmt.Errorf("read payload: %w", err)
+	}
+	if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(head[:]) {
+		return nil, 0, errors.New("checksum mismatch")
+	}
+	muts, err := decodeWALPayload(payload)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	size := int64(len(head)) + int64(uvarintLen(length)) + int64(length)
+	return muts, size, nil
+}
+
+func decodeWALPayload(payload []byte) ([]storeMutation[string, string], error) {
+	pr := bytes.NewReader(payload)
+	readString := func() (string, error) {
+		l, err := binary.ReadUvarint(pr)
+		if err != nil {
+			return "", err
+		}
+		if l > uint64(pr.Len()) {
+			return "", io.ErrUnexpectedEOF
+		}
+		b := make([]byte, l)
+		_, err = io.ReadFull(pr, b)
+		return string(b), err
+	}
+
+	count, err := binary.ReadUvarint(pr)
+	if err != nil {
+		return nil, err
+	}
+	var muts []storeMutation[string, string]
+	for i := uint64(0); i < count; i++ {
+		op, err := pr.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		var m storeMutation[string, string]
+		switch walOp(op) {
+		case walSet:
+		case walDelete:
+			m.Delete = true
+		default:
+			return nil, fmt.Errorf("unknown op %d", op)
+		}
+		if m.Key, err = readString(); err != nil {
+			return nil, err
+		}
+		if m.Value, err = readString(); err != nil {
+			return nil, err
+		}
+		expiresAt, err := binary.ReadVarint(pr)
+		if err != nil {
+			return nil, err
+		}
+		m.Deadline = timeOrZero(expiresAt)
+		muts = append(muts, m)
+	}
+	return muts, nil
+}
+
+func uvarintLen(v uint64) int {
+	var buf [binary.MaxVarintLen64]byte
+	return binary.PutUvarint(buf[:], v)
+}
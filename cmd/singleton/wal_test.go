@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func walConfig(t *testing.T) DurabilityConfig {
+	t.Helper()
+	dir := t.TempDir()
+	return DurabilityConfig{
+		SnapshotPath: filepath.Join(dir, "store.snap"),
+		WALPath:      filepath.Join(dir, "store.wal"),
+	}
+}
+
+// openDurable enables durability on a fresh singleton and closes the log
+// when the test ends.
+func openDurable(t *testing.T, cfg DurabilityConfig) *singleton {
+	t.Helper()
+	s := newSingleton()
+	if err := s.EnableDurability(cfg); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.DisableDurability() })
+	return s
+}
+
+func requireContents(t *testing.T, s *singleton, want map[string]string) {
+	t.Helper()
+	for k, v := range want {
+		if got, ok := s.Get(k); !ok || got != v {
+			t.Errorf("%s = %q, %t; want %q", k, got, ok, v)
+		}
+	}
+	if s.Size() != len(want) {
+		t.Errorf("size = %d, want %d", s.Size(), len(want))
+	}
+}
+
+func walSize(t *testing.T, cfg DurabilityConfig) int64 {
+	t.Helper()
+	fi, err := os.Stat(cfg.WALPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fi.Size()
+}
+
+func TestWALReplayAfterCrash(t *testing.T) {
+	cfg := walConfig(t)
+	s := openDurable(t, cfg)
+	s.Set("a", "1")
+	s.SetWithTTL("b", "2", time.Hour)
+	s.Set("gone", "x")
+	s.Delete("gone")
+	if err := s.Txn(func(tx *Tx) error {
+		tx.Set("c", "3")
+		tx.Set("a", "10")
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	// Nada de DisableDurability: o processo "morre" com o log aberto
+	restarted := openDurable(t, cfg)
+	requireContents(t, restarted, map[string]string{"a": "10", "b": "2", "c": "3"})
+	if ttl, ok := restarted.TTL("b"); !ok || ttl <= 0 || ttl > time.Hour {
+		t.Errorf("TTL(b) = %v, %t after replay", ttl, ok)
+	}
+}
+
+func TestWALCorruptLog(t *testing.T) {
+	first := encodeWALRecord([]storeMutation[string, string]{{Key: "a", Value: "1"}})
+	second := encodeWALRecord([]storeMutation[string, string]{{Key: "b", Value: "2"}, {Key: "c", Value: "3"}})
+	intact := append(append([]byte(nil), first...), second...)
+
+	flip := func(b []byte, i int) []byte {
+		b = append([]byte(nil), b...)
+		b[i] ^= 0xff
+		return b
+	}
+	hugeLength := append(append([]byte(nil), first...), 0, 0, 0, 0)
+	hugeLength = append(hugeLength, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f)
+
+	tests := []struct {
+		name string
+		log  []byte
+		want map[string]string
+		keep int // Bytes do log que sobram depois do replay
+	}{
+		{"intact", intact, map[string]string{"a": "1", "b": "2", "c": "3"}, len(intact)},
+		{"empty", nil, map[string]string{}, 0},
+		{"flipped payload of the last record", flip(intact, len(intact)-1), map[string]string{"a": "1"}, len(first)},
+		{"flipped checksum of the first record", flip(intact, 0), map[string]string{}, 0},
+		{"huge length", hugeLength, map[string]string{"a": "1"}, len(first)},
+		{"garbage tail", append(append([]byte(nil), intact...), 0xde, 0xad), map[string]string{"a": "1", "b": "2", "c": "3"}, len(intact)},
+	}
+	// Toda truncagem no meio do segundo registro perde só ele
+	for n := len(first) + 1; n < len(intact); n++ {
+		tests = append(tests, struct {
+			name string
+			log  []byte
+			want map[string]string
+			keep int
+		}{"truncated", intact[:n], map[string]string{"a": "1"}, len(first)})
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := walConfig(t)
+			if err := os.WriteFile(cfg.WALPath, tt.log, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			s := openDurable(t, cfg)
+			requireContents(t, s, tt.want)
+			if size := walSize(t, cfg); size != int64(tt.keep) {
+				t.Fatalf("log has %d bytes, want it cut back to %d", size, tt.keep)
+			}
+
+			// O que vem depois do corte é replayado normalmente
+			s.Set("d", "4")
+			if err := s.DisableDurability(); err != nil {
+				t.Fatal(err)
+			}
+			want := map[string]string{"d": "4"}
+			for k, v := range tt.want {
+				want[k] = v
+			}
+			requireContents(t, openDurable(t, cfg), want)
+		})
+	}
+}
+
+// tornFile writes half of each record and then fails, like a full disk.
+type tornFile struct {
+	walFile
+}
+
+func (f *tornFile) Write(p []byte) (int, error) {
+	n, _ := f.walFile.Write(p[:len(p)/2])
+	return n, io.ErrShortWrite
+}
+
+func TestWALFailedAppend(t *testing.T) {
+	cfg := walConfig(t)
+	s := openDurable(t, cfg)
+	s.Set("a", "1")
+
+	w := s.wal.Load()
+	w.mu.Lock()
+	healthy := w.file
+	w.file = &tornFile{healthy}
+	w.mu.Unlock()
+
+	s.Set("b", "2")
+	if err := s.DurabilityErr(); !errors.Is(err, ErrNotDurable) || !errors.Is(err, io.ErrShortWrite) {
+		t.Fatalf("DurabilityErr = %v, want ErrNotDurable wrapping the write error", err)
+	}
+	if v, ok := s.Get("b"); !ok || v != "2" {
+		t.Errorf("b = %q, %t; the write must stay applied in memory", v, ok)
+	}
+	good := int64(len(encodeWALRecord([]storeMutation[string, string]{{Key: "a", Value: "1"}})))
+	if size := walSize(t, cfg); size != good {
+		t.Errorf("torn record left in the log: size %d, want %d", size, good)
+	}
+
+	w.mu.Lock()
+	w.file = healthy
+	w.mu.Unlock()
+	// Depois da falha nada mais é anexado, nem com o arquivo de volta
+	if err := s.Txn(func(tx *Tx) error { tx.Set("c", "3"); return nil }); !errors.Is(err, ErrNotDurable) {
+		t.Errorf("Txn = %v, want ErrNotDurable", err)
+	}
+	crashed := newSingleton()
+	if err := crashed.EnableDurability(cfg); err != nil {
+		t.Fatal(err)
+	}
+	requireContents(t, crashed, map[string]string{"a": "1"})
+	crashed.DisableDurability()
+
+	// Compact grava tudo num snapshot e volta a registrar
+	if err := s.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DurabilityErr(); err != nil {
+		t.Errorf("DurabilityErr after Compact = %v", err)
+	}
+	s.Set("d", "4")
+	requireContents(t, openDurable(t, cfg), map[string]string{"a": "1", "b": "2", "c": "3", "d": "4"})
+}
+
+func TestWALLoadSnapshot(t *testing.T) {
+	cfg := walConfig(t)
+	s := openDurable(t, cfg)
+	s.Set("a", "1")
+	s.Set("b", "2")
+
+	other := newSingleton()
+	other.Set("x", "9")
+	var snap bytes.Buffer
+	if err := other.SaveSnapshot(&snap); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.LoadSnapshot(&snap); err != nil {
+		t.Fatal(err)
+	}
+	if size := walSize(t, cfg); size != 0 {
+		t.Errorf("log not checkpointed after load: %d bytes", size)
+	}
+	s.Set("y", "8")
+	// Sem o checkpoint, o replay traria a e b de volta
+	requireContents(t, openDurable(t, cfg), map[string]string{"x": "9", "y": "8"})
+}
+
+func TestWALCompactThreshold(t *testing.T) {
+	cfg := walConfig(t)
+	cfg.CompactThreshold = 64
+	s := openDurable(t, cfg)
+	want := make(map[string]string)
+	for _, k := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		s.Set(k, "value of "+k)
+		want[k] = "value of " + k
+	}
+	if size := walSize(t, cfg); size >= cfg.CompactThreshold {
+		t.Errorf("log was not compacted: %d bytes", size)
+	}
+	requireContents(t, openDurable(t, cfg), want)
+}
+
+func TestWALFsyncInterval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		cfg := walConfig(t)
+		cfg.Fsync, cfg.FsyncInterval = FsyncInterval, interval
+		if err := newSingleton().EnableDurability(cfg); err == nil {
+			t.Errorf("interval %v accepted", interval)
+		}
+	}
+
+	cfg := walConfig(t)
+	cfg.Fsync, cfg.FsyncInterval = FsyncInterval, time.Millisecond
+	s := openDurable(t, cfg)
+	s.Set("a", "1")
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		w := s.wal.Load()
+		w.mu.Lock()
+		dirty := w.dirty
+		w.mu.Unlock()
+		if !dirty {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("log never synced")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// EventKind tells why a watched key changed.
+type EventKind int
+
+const (
+	EventSet EventKind = iota
+	EventDelete
+	EventExpire
+	EventEvict
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventSet:
+		return "set"
+	case EventDelete:
+		return "delete"
+	case EventExpire:
+		return "expire"
+	case EventEvict:
+		return "evict"
+	}
+	return "unknown"
+}
+
+// StoreEvent describes one change. OldValue is only meaningful when
+// HadOld is true; NewValue only for EventSet.
+type StoreEvent[K comparable, V any] struct {
+	Kind     EventKind
+	Key      K
+	OldValue V
+	HadOld   bool
+	NewValue V
+}
+
+// Event is the change notification of the string singleton store.
+type Event = StoreEvent[string, string]
+
+// OverflowPolicy decides what happens when a subscriber buffer is full.
+type OverflowPolicy int
+
+const (
+	// OverflowDrop discards the event for that subscriber only.
+	OverflowDrop OverflowPolicy = iota
+	// OverflowBlock never drops: events that do not fit in the buffer
+	// wait in a queue of their own, and a goroutine per subscriber blocks
+	// until it takes them. Writers never wait for the subscriber, so it
+	// may write to the store it watches, but the queue grows for as long
+	// as it falls behind.
+	OverflowBlock
+)
+
+type watchConfig struct {
+	buffer   int
+	overflow OverflowPolicy
+}
+
+// WatchOption configures a subscription.
+type WatchOption func(*watchConfig)
+
+// WithWatchBuffer sets the channel capacity of a subscription (default 64).
+func WithWatchBuffer(n int) WatchOption {
+	return func(c *watchConfig) { c.buffer = n }
+}
+
+// WithWatchOverflow sets what to do when the subscriber falls behind.
+func WithWatchOverflow(p OverflowPolicy) WatchOption {
+	return func(c *watchConfig) { c.overflow = p }
+}
+
+type subscriber[K comparable, V any] struct {
+	match    func(K) bool
+	ch       chan StoreEvent[K, V]
+	done     chan struct{}
+	overflow OverflowPolicy
+	dropped  atomic.Uint64
+
+	// Só para OverflowBlock: fila entregue por pump, em ordem
+	mu       sync.Mutex
+	queue    []StoreEvent[K, V]
+	wake     chan struct{}
+	pumpDone chan struct{}
+}
+
+// watchHub delivers event batches in the order the store produced them.
+// Writers take a ticket (batchSeq) under the store lock and wait for their
+// turn here only after releasing it. Delivery never blocks, so no writer
+// waits on a subscriber while holding a turn.
+type watchHub[K comparable, V any] struct {
+	mu   sync.Mutex
+	turn *sync.Cond
+	next uint64
+	subs map[*subscriber[K, V]]struct{}
+}
+
+// WatchFunc subscribes to changes of the keys accepted by match. The
+// channel is closed after cancel is called.
+func (s *Store[K, V]) WatchFunc(match func(K) bool, opts ...WatchOption) (<-chan StoreEvent[K, V], func()) {
+	cfg := watchConfig{buffer: 64}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.buffer < 0 {
+		cfg.buffer = 0
+	}
+	sub := &subscriber[K, V]{
+		match:    match,
+		ch:       make(chan StoreEvent[K, V], cfg.buffer),
+		done:     make(chan struct{}),
+		overflow: cfg.overflow,
+	}
+	if sub.overflow == OverflowBlock {
+		sub.wake = make(chan struct{}, 1)
+		sub.pumpDone = make(chan struct{})
+		go sub.pump()
+	}
+
+	h := &s.hub
+	h.mu.Lock()
+	if h.subs == nil {
+		h.subs = make(map[*subscriber[K, V]]struct{})
+	}
+	h.subs[sub] = struct{}{}
+	s.watchers.Add(1)
+	h.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			close(sub.done)
+			h.mu.Lock()
+			delete(h.subs, sub)
+			s.watchers.Add(-1)
+			h.mu.Unlock()
+			if sub.pumpDone != nil {
+				// pump fecha o canal ao sair
+				<-sub.pumpDone
+				return
+			}
+			close(sub.ch)
+		})
+	}
+	return sub.ch, cancel
+}
+
+// Watch subscribes to Set, Delete, Expire and Evict events for every key
+// starting with prefix.
+func (s *singleton) Watch(prefix string, opts ...WatchOption) (<-chan Event, func()) {
+	return s.WatchFunc(func(key string) bool {
+		return strings.HasPrefix(key, prefix)
+	}, opts...)
+}
+
+// recordLocked queues ev for the watchers. Caller holds the write lock.
+func (s *Store[K, V]) recordLocked(ev StoreEvent[K, V]) {
+	if s.watchers.Load() == 0 {
+		return
+	}
+	s.pending = append(s.pending, ev)
+}
+
+// unlockAndNotify releases the write lock and then hands the events
+// queued under it to the watchers.
+func (s *Store[K, V]) unlockAndNotify() {
+	if len(s.pending) == 0 {
+		s.mutex.Unlock()
+		return
+	}
+	events := s.pending
+	s.pending = nil
+	seq := s.batchSeq
+	s.batchSeq++
+	s.mutex.Unlock()
+
+	s.hub.dispatch(seq, events)
+}
+
+func (h *watchHub[K, V]) dispatch(seq uint64, events []StoreEvent[K, V]) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.turn == nil {
+		h.turn = sync.NewCond(&h.mu)
+	}
+	for h.next != seq {
+		h.turn.Wait()
+	}
+	for sub := range h.subs {
+		for _, ev := range events {
+			if sub.match == nil || sub.match(ev.Key) {
+				sub.deliver(ev)
+			}
+		}
+	}
+	h.next++
+	h.turn.Broadcast()
+}
+
+// deliver hands ev to the subscriber without blocking. Caller holds the
+// hub lock, so a cancelled subscriber is never sent to.
+func (sub *subscriber[K, V]) deliver(ev StoreEvent[K, V]) {
+	if sub.overflow == OverflowBlock {
+		sub.mu.Lock()
+		sub.queue = append(sub.queue, ev)
+		sub.mu.Unlock()
+		select {
+		case sub.wake <- struct{}{}:
+		default:
+		}
+		return
+	}
+	select {
+	case sub.ch <- ev:
+	default:
+		sub.dropped.Add(1)
+	}
+}
+
+// pump moves the queue of an OverflowBlock subscriber into its channel,
+// waiting for the subscriber as long as it takes, until it cancels.
+func (sub *subscriber[K, V]) pump() {
+	defer close(sub.pumpDone)
+	defer close(sub.ch)
+	for {
+		sub.mu.Lock()
+		batch := sub.queue
+		sub.queue = nil
+		sub.mu.Unlock()
+
+		for _, ev := range batch {
+			select {
+			case sub.ch <- ev:
+			case <-sub.done:
+				return
+			}
+		}
+		select {
+		case <-sub.wake:
+		case <-sub.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// receive reads n events from ch or fails the test.
+func receive(t *testing.T, ch <-chan Event, n int) []Event {
+	t.Helper()
+	var events []Event
+	for range n {
+		select {
+		case ev, ok := <-ch:
+			if !ok {
+				t.Fatalf("channel closed after %d of %d events", len(events), n)
+			}
+			events = append(events, ev)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("got %d of %d events", len(events), n)
+		}
+	}
+	return events
+}
+
+func TestWatchOrder(t *testing.T) {
+	s := newSingleton(WithMaxEntries(2))
+	ch, cancel := s.Watch("k:")
+	defer cancel()
+
+	s.Set("k:a", "1")
+	s.Set("outro", "x") // Fora do prefixo
+	s.Set("k:a", "2")
+	s.Delete("k:a")
+	s.Set("k:b", "3")
+	s.Set("k:c", "4") // Passa do limite: "outro" sai, sem evento aqui
+	s.Set("k:d", "5") // Agora quem sai é k:b
+
+	want := []string{"set k:a=1", "set k:a=2", "delete k:a", "set k:b=3", "set k:c=4", "evict k:b", "set k:d=5"}
+	for i, ev := range receive(t, ch, len(want)) {
+		got := fmt.Sprintf("%v %s", ev.Kind, ev.Key)
+		if ev.Kind == EventSet {
+			got += "=" + ev.NewValue
+		}
+		if got != want[i] {
+			t.Errorf("event %d = %q, want %q", i, got, want[i])
+		}
+	}
+}
+
+func TestWatchOrderAcrossWriters(t *testing.T) {
+	s := newSingleton()
+	ch, cancel := s.Watch("", WithWatchBuffer(0), WithWatchOverflow(OverflowBlock))
+	defer cancel()
+
+	const writers, writes = 8, 50
+	var wg sync.WaitGroup
+	for w := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range writes {
+				s.Set("k", strconv.Itoa(w*writes+i))
+			}
+		}()
+	}
+	wg.Wait()
+	// O último evento entregue é o último valor gravado
+	events := receive(t, ch, writers*writes)
+	if got, _ := s.Get("k"); events[len(events)-1].NewValue != got {
+		t.Errorf("last event %q, store has %q", events[len(events)-1].NewValue, got)
+	}
+}
+
+func TestWatchOverflowDrop(t *testing.T) {
+	s := newSingleton()
+	ch, cancel := s.Watch("", WithWatchBuffer(1))
+	defer cancel()
+
+	for i := range 3 {
+		s.Set("k", strconv.Itoa(i))
+	}
+	if ev := receive(t, ch, 1)[0]; ev.NewValue != "0" {
+		t.Errorf("kept %q, want the first event", ev.NewValue)
+	}
+	select {
+	case ev := <-ch:
+		t.Errorf("got %+v, want the rest dropped", ev)
+	default:
+	}
+}
+
+func TestWatchOverflowBlock(t *testing.T) {
+	s := newSingleton()
+	ch, cancel := s.Watch("", WithWatchBuffer(1), WithWatchOverflow(OverflowBlock))
+	defer cancel()
+
+	// Ninguém lê durante as escritas, e elas não esperam por isso
+	for i := range 100 {
+		s.Set("k", strconv.Itoa(i))
+	}
+	for i, ev := range receive(t, ch, 100) {
+		if ev.NewValue != strconv.Itoa(i) {
+			t.Fatalf("event %d = %q", i, ev.NewValue)
+		}
+	}
+}
+
+func TestWatchSubscriberWritesBack(t *testing.T) {
+	s := newSingleton()
+	ch, cancel := s.Watch("pedido:", WithWatchBuffer(0), WithWatchOverflow(OverflowBlock))
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for ev := range ch {
+			// Escreve de volta no store que está observando
+			s.Set("visto:"+ev.Key, ev.NewValue)
+			if ev.Key == "pedido:9" {
+				return
+			}
+		}
+	}()
+	for i := range 10 {
+		s.Set(fmt.Sprintf("pedido:%d", i), "ok")
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("subscriber writing back deadlocked")
+	}
+	if v, ok := s.Get("visto:pedido:9"); !ok || v != "ok" {
+		t.Errorf("write-back = %q, %t", v, ok)
+	}
+}
+
+func TestWatchCancel(t *testing.T) {
+	for _, policy := range []OverflowPolicy{OverflowDrop, OverflowBlock} {
+		s := newSingleton()
+		ch, cancel := s.Watch("", WithWatchBuffer(1), WithWatchOverflow(policy))
+		for i := range 5 {
+			s.Set("k", strconv.Itoa(i))
+		}
+		cancel()
+		cancel() // Idempotente
+		s.Set("k", "depois")
+
+		// O canal fecha depois do que já estava no buffer
+		left := 0
+		for range ch {
+			left++
+		}
+		if left > 1 {
+			t.Errorf("policy %d: %d events after cancel, want at most the buffered one", policy, left)
+		}
+		if n := s.watchers.Load(); n != 0 {
+			t.Errorf("policy %d: %d watchers left", policy, n)
+		}
+	}
+}